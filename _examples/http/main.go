@@ -8,7 +8,7 @@ import (
 
 func main() {
 	static := qrpix.NewStatic(
-		"123e4567-e12b-12d1-a456-426655440000",
+		"123e4567-e12b-42d1-a456-426655440000",
 		"Fulano de Tal",
 		"BRASILIA",
 		"***",
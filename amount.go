@@ -0,0 +1,85 @@
+package qrpix
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Transaction amount in cents. Amounts are parsed and formatted as decimal
+// strings without going through floats, so every value is exact.
+type Amount int64
+
+// Largest amount that fits the transaction amount field (13 chars)
+const MaxAmount Amount = 999999999999
+
+// Parses a decimal amount in reais, such as "10.50", "10.5" or "10". Values
+// with more than 2 decimal places can't be represented and are rejected.
+func ParseAmount(value string) (Amount, error) {
+	whole, decimals, found := strings.Cut(value, ".")
+	if !isDigits(whole) || len(decimals) > 2 || (found && !isDigits(decimals)) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	// Anything longer could overflow and wouldn't fit the field anyway
+	if len(whole) > len(strconv.FormatInt(int64(MaxAmount/100), 10)) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	reais, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	var cents int64
+	for i := 0; i < 2; i++ {
+		cents *= 10
+		if i < len(decimals) {
+			cents += int64(decimals[i] - '0')
+		}
+	}
+	return Amount(reais*100 + cents), nil
+}
+
+// Formats the amount in reais with 2 decimal places, e.g. 1050 as "10.50"
+func (a Amount) String() string {
+	sign := ""
+	// Negated as unsigned, since the smallest amount has no positive int64
+	n := uint64(a)
+	if a < 0 {
+		sign = "-"
+		n = -n
+	}
+	cents := strconv.FormatUint(n%100, 10)
+	if len(cents) == 1 {
+		cents = "0" + cents
+	}
+	return sign + strconv.FormatUint(n/100, 10) + "." + cents
+}
+
+// Reports whether the amount follows the strict grammar of the transaction
+// amount field: a plain decimal with "." and two decimal places, such as
+// "10.50".
+func isValidAmount[T string | []byte](value T) bool {
+	n := len(value)
+	if n < 4 || value[n-3] != '.' {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if i != n-3 && (value[i] < '0' || value[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Rewrites lenient amounts such as "10" or "10.5" in the strict format.
+// Values that aren't amounts at all are returned unchanged.
+func normalizeAmount(value string) string {
+	if isValidAmount(value) {
+		return value
+	}
+	amount, err := ParseAmount(value)
+	if err != nil {
+		return value
+	}
+	return amount.String()
+}
@@ -0,0 +1,163 @@
+package qrpix
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"testing/quick"
+)
+
+func TestAmount(t *testing.T) {
+	t.Run("should parse decimal amounts", func(t *testing.T) {
+		cases := []struct {
+			value    string
+			expected Amount
+		}{
+			{value: "10.50", expected: 1050},
+			{value: "10.5", expected: 1050},
+			{value: "10", expected: 1000},
+			{value: "0.29", expected: 29},
+			{value: "0.01", expected: 1},
+			{value: "0.00", expected: 0},
+			{value: "007.10", expected: 710},
+			{value: "9999999999.99", expected: MaxAmount},
+		}
+		for _, c := range cases {
+			amount, err := ParseAmount(c.value)
+			if err != nil {
+				t.Errorf("unexpected error for %s: %v", c.value, err)
+				continue
+			}
+			if amount != c.expected {
+				t.Errorf("expected %d for %s but got %d", c.expected, c.value, amount)
+			}
+		}
+	})
+
+	t.Run("should reject invalid amounts", func(t *testing.T) {
+		cases := []string{"", ".", ".50", "10.", "1.234", "1e3", "-1.00", "+1.00", "1,00", "abc", " 1.00", "10.5a", "99999999999.00"}
+		for _, value := range cases {
+			if _, err := ParseAmount(value); !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("expected ErrInvalidAmount for %q but got: %v", value, err)
+			}
+		}
+	})
+
+	t.Run("should format with 2 decimal places", func(t *testing.T) {
+		cases := []struct {
+			amount   Amount
+			expected string
+		}{
+			{amount: 0, expected: "0.00"},
+			{amount: 1, expected: "0.01"},
+			{amount: 29, expected: "0.29"},
+			{amount: 1050, expected: "10.50"},
+			{amount: -105, expected: "-1.05"},
+			{amount: MaxAmount, expected: "9999999999.99"},
+			{amount: math.MaxInt64, expected: "92233720368547758.07"},
+			{amount: math.MinInt64, expected: "-92233720368547758.08"},
+		}
+		for _, c := range cases {
+			if got := c.amount.String(); got != c.expected {
+				t.Errorf("expected %s but got %s", c.expected, got)
+			}
+		}
+	})
+
+	t.Run("every cent value should round trip through strings", func(t *testing.T) {
+		// Reais and cents are formatted independently, so every cent value
+		// is checked against reais of every length plus a random sample,
+		// rather than every amount up to MaxAmount
+		wholes := []int64{0, 1, 9, 10, 99, 100, 999, 1000, 12345, 999999, 1000000, 123456789, 999999999, 1000000000, 9999999999}
+		for i := 0; i < 1000; i++ {
+			wholes = append(wholes, rand.Int63n(int64(MaxAmount/100)+1))
+		}
+		for _, whole := range wholes {
+			for cents := int64(0); cents < 100; cents++ {
+				amount := Amount(whole*100 + cents)
+				if amount == 0 {
+					continue
+				}
+				checkAmountRoundTrip(t, amount)
+			}
+		}
+	})
+
+	t.Run("random amounts should round trip through strings", func(t *testing.T) {
+		f := func(n uint64) bool {
+			amount := Amount(n%uint64(MaxAmount)) + 1
+			return checkAmountRoundTrip(t, amount)
+		}
+		if err := quick.Check(f, &quick.Config{MaxCount: 100000}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("random amounts should round trip through codes", func(t *testing.T) {
+		f := func(n uint64) bool {
+			amount := Amount(n%uint64(MaxAmount)) + 1
+			qr := NewStatic("52998224725", "Fulano de Tal", "BRASILIA", "***", WithTransactionAmount(amount))
+			code, err := qr.BRCode()
+			if err != nil {
+				t.Logf("failed to build code for %d: %v", amount, err)
+				return false
+			}
+			static, err := NewParser().ParseStatic(code)
+			if err != nil {
+				t.Logf("failed to parse code for %d: %v", amount, err)
+				return false
+			}
+			return static.TransactionAmount == amount
+		}
+		boundaries := []Amount{1, 9, 10, 29, 99, 100, 101, MaxAmount - 1, MaxAmount}
+		for _, amount := range boundaries {
+			if !f(uint64(amount - 1)) {
+				t.Errorf("amount %d didn't round trip", amount)
+			}
+		}
+		if err := quick.Check(f, &quick.Config{MaxCount: 5000}); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// Checks that the amount is formatted within the field limit and parsed back
+func checkAmountRoundTrip(t *testing.T, amount Amount) bool {
+	t.Helper()
+	value := amount.String()
+	if err := ValidateField("54", value); err != nil {
+		t.Errorf("formatted amount %s is not valid: %v", value, err)
+		return false
+	}
+	parsed, err := ParseAmount(value)
+	if err != nil {
+		t.Errorf("failed to parse %s: %v", value, err)
+		return false
+	}
+	if parsed != amount {
+		t.Errorf("expected %d but got %d for %s", amount, parsed, value)
+		return false
+	}
+	return true
+}
+
+func FuzzAmount(f *testing.F) {
+	for _, value := range []string{"10.50", "10.5", "10", "0.29", "9999999999.99", "1e3", "-1"} {
+		f.Add(value)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		amount, err := ParseAmount(value)
+		if err != nil {
+			return
+		}
+		if amount < 0 || amount > MaxAmount {
+			t.Fatalf("parsed %q out of range as %d", value, amount)
+		}
+		parsed, err := ParseAmount(amount.String())
+		if err != nil || parsed != amount {
+			t.Fatalf("%q parsed as %d didn't round trip: %d, %v", value, amount, parsed, err)
+		}
+	})
+}
@@ -2,19 +2,27 @@ package qrpix
 
 import (
 	"errors"
-	"fmt"
-	"strconv"
+	"strings"
 
-	"github.com/snksoft/crc"
 	"golang.org/x/exp/slices"
 )
 
 var (
-	ErrInvalidCRC              = errors.New("crc is not valid")
-	ErrCRCNotPresent           = errors.New("crc is not present")
-	ErrRequiredFieldNotPresent = errors.New("required field not present")
+	ErrInvalidCRC                  = errors.New("crc is not valid")
+	ErrCRCNotPresent               = errors.New("crc is not present")
+	ErrRequiredFieldNotPresent     = errors.New("required field not present")
+	ErrInvalidUnreservedTemplateID = errors.New("unreserved template id must be between 80 and 99")
+	ErrInvalidUnreservedValueID    = errors.New("unreserved template value id must be between 01 and 99")
 )
 
+// Partner specific data carried in unreserved templates (80-99)
+type UnreservedTemplate struct {
+	// Globally unique identifier of the template owner, stored in value 00
+	GUI string `json:"gui"`
+	// Context specific values by id (01-99)
+	Values map[string]string `json:"values"`
+}
+
 // Used to build the BRCode
 type Builder map[string]TLV
 
@@ -24,7 +32,7 @@ func (b Builder) Add(tlv TLV) Builder {
 }
 
 func (b Builder) toSlice() []TLV {
-	tlvs := []TLV{}
+	tlvs := make([]TLV, 0, len(b))
 	for _, tlv := range b {
 		tlvs = append(tlvs, tlv)
 	}
@@ -33,48 +41,108 @@ func (b Builder) toSlice() []TLV {
 
 func (b Builder) Sorted() []TLV {
 	tlvs := b.toSlice()
-	slices.SortFunc[TLV](tlvs, func(a, b TLV) bool {
-		aid, _ := strconv.Atoi(a.FieldID())
-		bid, _ := strconv.Atoi(b.FieldID())
-		return aid < bid
-	})
+	sortTLVs(tlvs)
 	return tlvs
 }
 
-// Build and validates the BRCode. CRC16 is added automatically.
+// Build and validates the BRCode, allowing any UTF-8 value. CRC16 is added
+// automatically, replacing any CRC already present in the builder.
 func (b Builder) Build() (string, error) {
-	var (
-		res  string
-		tlvs = b.Sorted()
-	)
+	return b.BuildWithCharset(CharsetUTF8)
+}
 
-	for _, tlv := range tlvs {
-		id, length, value, err := tlv.TLV()
-		if err != nil {
-			return "", err
-		}
-		res += id + length + value
+// Build and validates the BRCode, applying the charset policy to every value.
+// CRC16 is added automatically, replacing any CRC already present in the builder.
+func (b Builder) BuildWithCharset(c Charset) (string, error) {
+	return b.encode(c, true, true)
+}
+
+// Builds without the CRC field
+func (b Builder) buildRaw() (string, error) {
+	return b.encode(CharsetUTF8, false, false)
+}
+
+func (b Builder) encode(c Charset, checkCharset, withCRC bool) (string, error) {
+	return encodeTLVs(b.Sorted(), c, checkCharset, withCRC)
+}
+
+// Returns the primitives and templates as TLVs sorted by id. The TLVs point
+// into the slices, so they aren't allocated one by one.
+func sortedTLVs(primitives []Primitive, templates []Template) []TLV {
+	tlvs := make([]TLV, 0, len(primitives)+len(templates))
+	for i := range primitives {
+		tlvs = append(tlvs, &primitives[i])
 	}
+	for i := range templates {
+		tlvs = append(tlvs, &templates[i])
+	}
+	sortTLVs(tlvs)
+	return tlvs
+}
 
-	res = b.addCRC16(res)
-	return res, nil
+func sortTLVs(tlvs []TLV) {
+	// Ids are always two digits, so comparing strings matches numeric order
+	slices.SortFunc[TLV](tlvs, func(a, b TLV) bool {
+		return a.FieldID() < b.FieldID()
+	})
 }
 
-func (b Builder) buildRaw() (string, error) {
+// Encoded field waiting to be written
+type encodedField struct {
+	id, length, value string
+}
+
+// Writes every field but the CRC, sorted by id, into a single buffer sized
+// upfront so the code is allocated once. Values are transliterated with
+// CharsetTransliterate and checked against the charset when checkCharset is
+// set. The CRC is appended when withCRC is set.
+func encodeTLVs(tlvs []TLV, c Charset, checkCharset, withCRC bool) (string, error) {
 	var (
-		res  string
-		tlvs = b.Sorted()
+		fields = make([]encodedField, 0, len(tlvs))
+		size   int
 	)
 
 	for _, tlv := range tlvs {
+		if tlv.FieldID() == "63" {
+			continue
+		}
+		if c == CharsetTransliterate {
+			converted, err := transliterateTLV(tlv)
+			if err != nil {
+				return "", err
+			}
+			tlv = converted
+		}
 		id, length, value, err := tlv.TLV()
 		if err != nil {
 			return "", err
 		}
-		res += id + length + value
+		if value == "" {
+			continue
+		}
+		if checkCharset {
+			if err := c.validate(value); err != nil {
+				return "", newFieldError(id, value, ReasonInvalidCharset)
+			}
+		}
+		size += len(id) + len(length) + len(value)
+		fields = append(fields, encodedField{id: id, length: length, value: value})
 	}
 
-	return res, nil
+	var sb strings.Builder
+	if withCRC {
+		size += crcFieldSize
+	}
+	sb.Grow(size)
+	for _, f := range fields {
+		sb.WriteString(f.id)
+		sb.WriteString(f.length)
+		sb.WriteString(f.value)
+	}
+	if withCRC {
+		writeCRC16(&sb)
+	}
+	return sb.String(), nil
 }
 
 // Clears the builder TLV items
@@ -93,13 +161,37 @@ func (b Builder) GetPayloadFormatIndicator() (string, error) {
 	return b.GetPrimitiveField("00")
 }
 
+func (b Builder) AddPointOfInitiationMethod(method string) {
+	b.Add(&Primitive{
+		ID:    "01",
+		Value: method,
+	})
+}
+
+func (b Builder) GetPointOfInitiationMethod() (string, error) {
+	return b.GetPrimitiveField("01")
+}
+
 func (b Builder) AddMerchantAccountInformation(gui, chave string) {
-	t := &Template{
+	t := newMerchantAccountInformation(gui, "01", chave)
+	b.Add(&t)
+}
+
+// Adds the merchant account information of a dynamic code, where the
+// payload location (URL) replaces the chave.
+func (b Builder) AddDynamicMerchantAccountInformation(gui, url string) {
+	t := newMerchantAccountInformation(gui, "25", url)
+	b.Add(&t)
+}
+
+// Creates the merchant account information with the chave (01) or the URL (25)
+func newMerchantAccountInformation(gui, id, value string) Template {
+	t := Template{
 		ID: "26",
 	}
 	t.AddValue("00", gui)
-	t.AddValue("01", chave)
-	b.Add(t)
+	t.AddValue(id, value)
+	return t
 }
 
 func (b Builder) GetMerchantAccountInformationGui() (string, error) {
@@ -110,6 +202,10 @@ func (b Builder) GetMerchantAccountInformationChave() (string, error) {
 	return b.GetTemplateField("26", "01")
 }
 
+func (b Builder) GetMerchantAccountInformationURL() (string, error) {
+	return b.GetTemplateField("26", "25")
+}
+
 func (b Builder) AddMerchantCategoryCode(code string) {
 	b.Add(&Primitive{
 		ID:    "52",
@@ -133,18 +229,18 @@ func (b Builder) GetTransactionCurrency() (string, error) {
 }
 
 // Adds the transaction amount in cents. Ex: 100 == 1 real
-func (b Builder) AddTransactionAmount(amount int) {
+func (b Builder) AddTransactionAmount(amount Amount) {
 	if amount == 0 {
 		return
 	}
 
 	b.Add(&Primitive{
 		ID:    "54",
-		Value: fmt.Sprintf("%.2f", float64(amount)/float64(100)),
+		Value: amount.String(),
 	})
 }
 
-func (b Builder) GetTransactionAmount() (int, error) {
+func (b Builder) GetTransactionAmount() (Amount, error) {
 	val, err := b.GetPrimitiveField("54")
 	if err != nil {
 		return 0, err
@@ -152,12 +248,11 @@ func (b Builder) GetTransactionAmount() (int, error) {
 	if val == "" {
 		return 0, nil
 	}
-	f, err := strconv.ParseFloat(val, 64)
+	amount, err := ParseAmount(val)
 	if err != nil {
-		return 0, err
+		return 0, newFieldError("54", val, ReasonInvalidAmount)
 	}
-
-	return int(f * float64(100)), nil
+	return amount, nil
 }
 
 func (b Builder) AddCountryCode(code string) {
@@ -205,24 +300,112 @@ func (b Builder) GetPostalCode() (string, error) {
 }
 
 func (b Builder) AddAdditionalDataField(transactionId string) {
-	t := &Template{
+	t := newAdditionalDataField(transactionId)
+	b.Add(&t)
+}
+
+func newAdditionalDataField(transactionId string) Template {
+	t := Template{
 		ID: "62",
 	}
 	t.AddValue("05", transactionId)
-	b.Add(t)
+	return t
 }
 
 func (b Builder) GetTransactionId() (string, error) {
 	return b.GetTemplateField("62", "05")
 }
 
+// Adds an unreserved template. Values must use ids between 01 and 99, since 00
+// holds the GUI.
+func (b Builder) AddUnreservedTemplate(id string, ut UnreservedTemplate) error {
+	t, err := newUnreservedTemplate(id, ut)
+	if err != nil {
+		return err
+	}
+	b.Add(&t)
+	return nil
+}
+
+func newUnreservedTemplate(id string, ut UnreservedTemplate) (Template, error) {
+	if !isUnreservedTemplateID(id) {
+		return Template{}, ErrInvalidUnreservedTemplateID
+	}
+	t := Template{
+		ID:     id,
+		values: make(map[string]Primitive, len(ut.Values)+1),
+	}
+	t.AddValue("00", ut.GUI)
+	for vid, value := range ut.Values {
+		// 00 holds the GUI, so it can't be overwritten by a value
+		if vid == "00" {
+			return Template{}, ErrInvalidUnreservedValueID
+		}
+		t.AddValue(vid, value)
+	}
+	return t, nil
+}
+
+// Gets an unreserved template. Returns the zero value if the template is not present.
+func (b Builder) GetUnreservedTemplate(id string) (UnreservedTemplate, error) {
+	if !isUnreservedTemplateID(id) {
+		return UnreservedTemplate{}, ErrInvalidUnreservedTemplateID
+	}
+	if _, ok := b[id]; !ok {
+		return UnreservedTemplate{}, nil
+	}
+
+	gui, err := b.GetTemplateField(id, "00")
+	if err != nil {
+		return UnreservedTemplate{}, err
+	}
+	ut := UnreservedTemplate{
+		GUI:    gui,
+		Values: map[string]string{},
+	}
+	for vid := range b[id].Unwrap() {
+		if vid == "00" {
+			continue
+		}
+		value, err := b.GetTemplateField(id, vid)
+		if err != nil {
+			return UnreservedTemplate{}, err
+		}
+		ut.Values[vid] = value
+	}
+	return ut, nil
+}
+
+// Gets all unreserved templates present in the builder by id
+func (b Builder) GetUnreservedTemplates() (map[string]UnreservedTemplate, error) {
+	var templates map[string]UnreservedTemplate
+	for id := range b {
+		if !isUnreservedTemplateID(id) {
+			continue
+		}
+		ut, err := b.GetUnreservedTemplate(id)
+		if err != nil {
+			return nil, err
+		}
+		if templates == nil {
+			templates = map[string]UnreservedTemplate{}
+		}
+		templates[id] = ut
+	}
+	return templates, nil
+}
+
 func (b Builder) addCRC16(data string) string {
-	appended := data + "6304"
-	ccittCrc := crc.CalculateCRC(crc.CCITT, []byte(appended))
-	return fmt.Sprintf("%s%04X", appended, ccittCrc)
+	var sb strings.Builder
+	sb.Grow(len(data) + crcFieldSize)
+	sb.WriteString(data)
+	writeCRC16(&sb)
+	return sb.String()
 }
 
-// Removes crc and then builds without adding it and checks if hashes matches
+// Builds without the CRC and checks if it matches the CRC field. The builder
+// is not modified, so the check can be repeated. Fields are built in canonical
+// order, use VerifyCRC to check a code as received.
 func (b Builder) CheckCRC() error {
 	crcField, ok := b["63"]
 	if !ok {
@@ -233,31 +416,99 @@ func (b Builder) CheckCRC() error {
 		return err
 	}
 
-	delete(b, "63") // Remove CRC from map
-
 	r, err := b.buildRaw()
 	if err != nil {
 		return err
 	}
 
-	appended := r + "6304"
-	ccittCrc := crc.CalculateCRC(crc.CCITT, []byte(appended))
-	if fmt.Sprintf("%04X", ccittCrc) != value {
+	if computeCRC16(r+"6304") != value {
 		return ErrInvalidCRC
 	}
 
 	return nil
 }
 
+// Verifies the CRC of a code as received, computing it over the original
+// bytes. The CRC must be the last field. Fields aren't parsed, so codes in
+// any field order can be verified.
+func VerifyCRC(code string) error {
+	return verifyCRC(code, false)
+}
+
+// Verifies the CRC field at the end of the code, ignoring the case of the hex
+// digits when foldCase is set
+func verifyCRC[T string | []byte](code T, foldCase bool) error {
+	n := len(code)
+	if n < crcFieldSize {
+		return ErrCRCNotPresent
+	}
+	for i := 0; i < 4; i++ {
+		if code[n-crcFieldSize+i] != "6304"[i] {
+			return ErrCRCNotPresent
+		}
+	}
+
+	crc := crc16(code[:n-4])
+	for i := 0; i < 4; i++ {
+		c := code[n-4+i]
+		if foldCase && c >= 'a' && c <= 'f' {
+			c -= 'a' - 'A'
+		}
+		if c != upperHex[crc>>(12-4*i)&0xF] {
+			return ErrInvalidCRC
+		}
+	}
+	return nil
+}
+
+// Recomputes the CRC of a code, replacing the CRC field if it's the last one
+// or appending it otherwise. A CRC field cut short, such as a trailing "6304"
+// without its value, is replaced as well. Fields are parsed leniently, so
+// codes with unknown fields can be repaired.
+func RepairCRC(code string) (string, error) {
+	s := parseState[string]{code: code, collect: true, lenient: true}
+	fields, err := s.parseFields()
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.Path == "63" && errors.Is(err, ErrUnexpectedEnd) {
+		// The CRC starts where the last complete field ends
+		start := 0
+		if len(fields) > 0 {
+			start = fields[len(fields)-1].End
+		}
+		return Builder{}.addCRC16(code[:start]), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	data := code
+	for i, f := range fields {
+		if f.ID != "63" {
+			continue
+		}
+		if i != len(fields)-1 {
+			return "", ErrFieldAfterCRC
+		}
+		data = code[:f.Start]
+	}
+
+	return Builder{}.addCRC16(data), nil
+}
+
+// Returns the CRC16 of the data as 4 uppercase hex digits
+func computeCRC16(data string) string {
+	return formatCRC16(crc16(data))
+}
+
 func (b Builder) GetPrimitiveField(id string) (string, error) {
 	meta, err := GetFieldMetadata(id)
 	if err != nil {
-		return "", err
+		return "", newFieldError(id, "", ReasonUnknownField)
 	}
 
 	tlv, ok := b[id]
 	if meta.Required && !ok {
-		return "", fmt.Errorf("required field not present: %s", meta.Name)
+		return "", newFieldError(id, "", ReasonNotPresent)
 	}
 
 	if !ok {
@@ -272,27 +523,26 @@ func (b Builder) GetPrimitiveField(id string) (string, error) {
 func (b Builder) GetTemplateField(id string, fieldId string) (string, error) {
 	tempMeta, err := GetFieldMetadata(id)
 	if err != nil {
-		return "", err
+		return "", newFieldError(id, "", ReasonUnknownField)
 	}
 
 	fieldMeta, err := GetFieldMetadata(id + "-" + fieldId)
 	if err != nil {
-		return "", err
+		return "", newFieldError(id+"-"+fieldId, "", ReasonUnknownField)
 	}
 
 	template, ok := b[id]
 	if !ok && tempMeta.Required {
-		return "", fmt.Errorf("required field not present: %s", tempMeta.Name)
+		return "", newFieldError(id, "", ReasonNotPresent)
 	}
-
-	vals := template.Unwrap()
-	if vals == nil && fieldMeta.Required {
-		return "", fmt.Errorf("required field not present: %s", fieldMeta.Name)
+	if !ok {
+		return "", nil
 	}
 
+	vals := template.Unwrap()
 	field, ok := vals[fieldId]
 	if !ok && fieldMeta.Required {
-		return "", fmt.Errorf("required field not present: %s", fieldMeta.Name)
+		return "", newFieldError(id+"-"+fieldId, "", ReasonNotPresent)
 	}
 	if !ok {
 		return "", nil
@@ -1,6 +1,7 @@
 package qrpix
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -99,10 +100,10 @@ func TestBuilder(t *testing.T) {
 		}
 	})
 
-	t.Run("add transaction amount should convert int to float", func(t *testing.T) {
+	t.Run("add transaction amount should format cents as decimal", func(t *testing.T) {
 		cases := []struct {
 			expected string
-			value    int
+			value    Amount
 		}{
 			{expected: "10.50", value: 1050},
 			{expected: "10.00", value: 1000},
@@ -137,4 +138,176 @@ func TestBuilder(t *testing.T) {
 		}
 	})
 
+	t.Run("unreserved template should be added and read back", func(t *testing.T) {
+		builder := Builder{}
+		ut := UnreservedTemplate{
+			GUI:    "com.example",
+			Values: map[string]string{"01": "store-42", "05": "loyalty-123"},
+		}
+		if err := builder.AddUnreservedTemplate("80", ut); err != nil {
+			t.Fatal(err)
+		}
+
+		code, err := builder["80"].Code()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "80420011com.example0108store-420511loyalty-123"
+		if code != expected {
+			t.Errorf("expected %s but got %s", expected, code)
+		}
+
+		got, err := builder.GetUnreservedTemplate("80")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.GUI != ut.GUI || len(got.Values) != 2 || got.Values["01"] != "store-42" || got.Values["05"] != "loyalty-123" {
+			t.Errorf("expected %+v but got %+v", ut, got)
+		}
+	})
+
+	t.Run("unreserved template outside of 80-99 should return error", func(t *testing.T) {
+		builder := Builder{}
+		for _, id := range []string{"26", "79", "100", "8a"} {
+			if err := builder.AddUnreservedTemplate(id, UnreservedTemplate{GUI: "com.example"}); !errors.Is(err, ErrInvalidUnreservedTemplateID) {
+				t.Errorf("expected ErrInvalidUnreservedTemplateID for id %s but got: %v", id, err)
+			}
+		}
+	})
+
+	t.Run("unreserved template with value 00 should return error", func(t *testing.T) {
+		builder := Builder{}
+		ut := UnreservedTemplate{GUI: "com.example", Values: map[string]string{"00": "evil"}}
+		if err := builder.AddUnreservedTemplate("80", ut); !errors.Is(err, ErrInvalidUnreservedValueID) {
+			t.Errorf("expected ErrInvalidUnreservedValueID but got: %v", err)
+		}
+		if _, ok := builder["80"]; ok {
+			t.Error("expected template not to be added")
+		}
+		if _, err := NewStatic("maria@email.com", "Maria", "OURO PRETO", "231dsad", WithUnreservedTemplate("80", ut)).BRCode(); !errors.Is(err, ErrInvalidUnreservedValueID) {
+			t.Errorf("expected ErrInvalidUnreservedValueID from static but got: %v", err)
+		}
+	})
+
+	t.Run("unreserved template without gui should fail to build", func(t *testing.T) {
+		builder := Builder{}
+		builder.AddUnreservedTemplate("99", UnreservedTemplate{Values: map[string]string{"01": "a"}})
+		if _, err := builder.Build(); !errors.Is(err, ErrFieldIsRequired) {
+			t.Errorf("expected ErrFieldIsRequired but got: %v", err)
+		}
+	})
+
+	t.Run("missing optional template field should be empty", func(t *testing.T) {
+		builder := Builder{}
+		txId, err := builder.GetTransactionId()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if txId != "" {
+			t.Errorf("expected empty transaction id but got %s", txId)
+		}
+	})
+
+	t.Run("check crc should not modify the builder", func(t *testing.T) {
+		p := NewParser()
+		builder, err := p.Parse(exampleCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := builder["63"]; !ok {
+			t.Fatal("expected parsed builder to keep the crc")
+		}
+		for i := 0; i < 2; i++ {
+			if err := builder.CheckCRC(); err != nil {
+				t.Errorf("unexpected error checking crc (%v): %v", i, err)
+			}
+		}
+		code, err := builder.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != exampleCode {
+			t.Errorf("expected %s but got %s", exampleCode, code)
+		}
+	})
+
+	t.Run("build should replace an outdated crc", func(t *testing.T) {
+		p := NewParser()
+		builder, err := p.Parse(exampleCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		builder.AddMerchantCity("RECIFE")
+		if err := builder.CheckCRC(); !errors.Is(err, ErrInvalidCRC) {
+			t.Errorf("expected ErrInvalidCRC but got: %v", err)
+		}
+		code, err := builder.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyCRC(code); err != nil {
+			t.Errorf("unexpected error verifying rebuilt code: %v", err)
+		}
+	})
+
+	t.Run("verify crc should check the code as received", func(t *testing.T) {
+		// Valid code with fields out of canonical order
+		unsorted := Builder{}.addCRC16("5204000000020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-42665544000053039865802BR5913Fulano de Tal6008BRASILIA62070503***")
+
+		cases := []struct {
+			code     string
+			expected error
+		}{
+			{code: exampleCode, expected: nil},
+			{code: unsorted, expected: nil},
+			{code: exampleCode[:len(exampleCode)-1] + "E", expected: ErrInvalidCRC},
+			{code: exampleCode[:len(exampleCode)-8], expected: ErrCRCNotPresent},
+			{code: "6304", expected: ErrCRCNotPresent},
+		}
+		for _, c := range cases {
+			if err := VerifyCRC(c.code); !errors.Is(err, c.expected) {
+				t.Errorf("expected %v for %s but got: %v", c.expected, c.code, err)
+			}
+		}
+
+		p := NewParser()
+		if _, err := p.Parse(unsorted); err == nil {
+			t.Error("expected parser to reject unsorted code")
+		}
+	})
+}
+
+func benchmarkStatic() *Static {
+	return NewStatic(
+		"123e4567-e12b-42d1-a456-426655440000",
+		"Fulano de Tal",
+		"BRASILIA",
+		"***",
+		WithTransactionAmount(1050),
+	)
+}
+
+func BenchmarkBuilderBuild(b *testing.B) {
+	builder := Builder{}
+	if errs := benchmarkStatic().fill(builder); len(errs) > 0 {
+		b.Fatal(errs)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := builder.Build(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStaticBRCode(b *testing.B) {
+	static := benchmarkStatic()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := static.BRCode(); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
@@ -0,0 +1,220 @@
+package qrpix
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidCharset = errors.New("value contains characters outside of the charset")
+)
+
+// Charset policy applied to field values when building and parsing codes.
+// Field lengths are always counted in bytes.
+type Charset int
+
+const (
+	// Allows any valid UTF-8 value, non-ASCII characters count as multiple
+	// bytes. This is the default policy.
+	CharsetUTF8 Charset = iota
+	// Only allows printable ASCII characters
+	CharsetASCII
+	// Transliterates accented latin characters to ASCII, failing for
+	// characters without a known transliteration.
+	CharsetTransliterate
+)
+
+func (c Charset) String() string {
+	switch c {
+	case CharsetUTF8:
+		return "UTF-8"
+	case CharsetASCII:
+		return "ASCII"
+	case CharsetTransliterate:
+		return "Transliterate"
+	default:
+		return "Unknown"
+	}
+}
+
+// Checks if the value is valid for the charset. Transliterated values are
+// only required to be valid UTF-8, since they are converted afterwards.
+func (c Charset) validate(value string) error {
+	if !inCharset(c, value) {
+		return fmt.Errorf("%w (%s): %q", ErrInvalidCharset, c, value)
+	}
+	return nil
+}
+
+// Reports whether the value is valid for the charset
+func inCharset[T string | []byte](c Charset, value T) bool {
+	if c == CharsetASCII {
+		for i := 0; i < len(value); i++ {
+			if value[i] < 0x20 || value[i] > 0x7e {
+				return false
+			}
+		}
+		return true
+	}
+	switch v := any(value).(type) {
+	case string:
+		return utf8.ValidString(v)
+	case []byte:
+		return utf8.Valid(v)
+	}
+	return false
+}
+
+var transliterations = map[rune]string{}
+
+func init() {
+	table := []struct {
+		from string
+		to   string
+	}{
+		{from: "ÀÁÂÃÄÅĀĂĄ", to: "A"},
+		{from: "àáâãäåāăąª", to: "a"},
+		{from: "ÇĆĈĊČ", to: "C"},
+		{from: "çćĉċč", to: "c"},
+		{from: "ĎĐ", to: "D"},
+		{from: "ďđ", to: "d"},
+		{from: "ÈÉÊËĒĔĖĘĚ", to: "E"},
+		{from: "èéêëēĕėęě", to: "e"},
+		{from: "ĜĞĠĢ", to: "G"},
+		{from: "ĝğġģ", to: "g"},
+		{from: "ÌÍÎÏĨĪĬĮİ", to: "I"},
+		{from: "ìíîïĩīĭįı", to: "i"},
+		{from: "ĹĻĽĿŁ", to: "L"},
+		{from: "ĺļľŀł", to: "l"},
+		{from: "ÑŃŅŇ", to: "N"},
+		{from: "ñńņň", to: "n"},
+		{from: "ÒÓÔÕÖØŌŎŐ", to: "O"},
+		{from: "òóôõöøōŏőº°", to: "o"},
+		{from: "ŔŖŘ", to: "R"},
+		{from: "ŕŗř", to: "r"},
+		{from: "ŚŜŞŠ", to: "S"},
+		{from: "śŝşš", to: "s"},
+		{from: "ŢŤ", to: "T"},
+		{from: "ţť", to: "t"},
+		{from: "ÙÚÛÜŨŪŬŮŰŲ", to: "U"},
+		{from: "ùúûüũūŭůűų", to: "u"},
+		{from: "ÝŸ", to: "Y"},
+		{from: "ýÿ", to: "y"},
+		{from: "ŹŻŽ", to: "Z"},
+		{from: "źżž", to: "z"},
+		{from: "Æ", to: "AE"},
+		{from: "æ", to: "ae"},
+		{from: "Œ", to: "OE"},
+		{from: "œ", to: "oe"},
+		{from: "ß", to: "ss"},
+		{from: "‘’´`", to: "'"},
+		{from: "“”", to: "\""},
+		{from: "–—", to: "-"},
+		{from: "\u00a0", to: " "},
+	}
+	for _, t := range table {
+		for _, r := range t.from {
+			transliterations[r] = t.to
+		}
+	}
+}
+
+// Converts the value to printable ASCII, replacing accented latin
+// characters by their unaccented form.
+func transliterate(value string) (string, error) {
+	if !utf8.ValidString(value) {
+		return "", fmt.Errorf("%w (%s): %q", ErrInvalidCharset, CharsetTransliterate, value)
+	}
+
+	b := strings.Builder{}
+	b.Grow(len(value))
+	for _, r := range value {
+		if r >= 0x20 && r <= 0x7e {
+			b.WriteRune(r)
+			continue
+		}
+		t, ok := transliterations[r]
+		if !ok {
+			return "", fmt.Errorf("%w (%s): %q", ErrInvalidCharset, CharsetTransliterate, value)
+		}
+		b.WriteString(t)
+	}
+	return b.String(), nil
+}
+
+// Returns a copy of the TLV with every value transliterated. Other TLV
+// implementations are returned as they are.
+func transliterateTLV(tlv TLV) (TLV, error) {
+	switch v := tlv.(type) {
+	case *Primitive:
+		p, err := v.transliterate()
+		return &p, err
+	case Primitive:
+		p, err := v.transliterate()
+		return &p, err
+	case *Template:
+		t, err := v.transliterate()
+		return &t, err
+	case Template:
+		t, err := v.transliterate()
+		return &t, err
+	default:
+		return tlv, nil
+	}
+}
+
+// Transliterates the values of parsed fields in place, returning the ids of
+// the changed values in the "parent-child" format
+func transliterateFields(fields []Field) ([]string, error) {
+	var changed []string
+	for i := range fields {
+		f := &fields[i]
+		if f.IsTemplate() {
+			for j := range f.Children {
+				c := &f.Children[j]
+				value, err := transliterate(c.Value)
+				if err != nil {
+					return nil, newFieldError(f.ID+"-"+c.ID, c.Value, ReasonInvalidCharset)
+				}
+				if value != c.Value {
+					c.Value = value
+					changed = append(changed, f.ID+"-"+c.ID)
+				}
+			}
+			continue
+		}
+		value, err := transliterate(f.Value)
+		if err != nil {
+			return nil, newFieldError(f.ID, f.Value, ReasonInvalidCharset)
+		}
+		if value != f.Value {
+			f.Value = value
+			changed = append(changed, f.ID)
+		}
+	}
+	return changed, nil
+}
+
+func (p Primitive) transliterate() (Primitive, error) {
+	value, err := transliterate(p.Value)
+	if err != nil {
+		return Primitive{}, newFieldError(p.validationId(), p.Value, ReasonInvalidCharset)
+	}
+	p.Value = value
+	return p, nil
+}
+
+func (t Template) transliterate() (Template, error) {
+	res := t
+	res.values = make(map[string]Primitive, len(t.values))
+	for id, v := range t.values {
+		p, err := v.transliterate()
+		if err != nil {
+			return Template{}, err
+		}
+		res.values[id] = p
+	}
+	return res, nil
+}
@@ -0,0 +1,142 @@
+package qrpix
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCharset(t *testing.T) {
+	t.Run("transliterate should remove accents", func(t *testing.T) {
+		cases := []struct {
+			value    string
+			expected string
+		}{
+			{value: "São Paulo", expected: "Sao Paulo"},
+			{value: "José", expected: "Jose"},
+			{value: "AÇAÍ DO JOÃO", expected: "ACAI DO JOAO"},
+			{value: "Straße", expected: "Strasse"},
+			{value: "plain", expected: "plain"},
+		}
+		for _, c := range cases {
+			got, err := transliterate(c.value)
+			if err != nil {
+				t.Errorf("unexpected error for %q: %v", c.value, err)
+			}
+			if got != c.expected {
+				t.Errorf("expected %q but got %q", c.expected, got)
+			}
+		}
+	})
+
+	t.Run("transliterate should fail for unknown characters", func(t *testing.T) {
+		for _, value := range []string{"東京", "\xff", "tab\t"} {
+			if _, err := transliterate(value); !errors.Is(err, ErrInvalidCharset) {
+				t.Errorf("expected ErrInvalidCharset for %q but got: %v", value, err)
+			}
+		}
+	})
+
+	t.Run("build should apply the charset policy", func(t *testing.T) {
+		builder := Builder{}
+		builder.AddMerchantName("José")
+
+		if _, err := builder.BuildWithCharset(CharsetASCII); !errors.Is(err, ErrInvalidCharset) {
+			t.Errorf("expected ErrInvalidCharset but got: %v", err)
+		}
+
+		code, err := builder.BuildWithCharset(CharsetUTF8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code[:9] != "5905José" {
+			t.Errorf("expected length to be counted in bytes but got %s", code)
+		}
+
+		code, err = builder.BuildWithCharset(CharsetTransliterate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code[:8] != "5904Jose" {
+			t.Errorf("expected transliterated value but got %s", code)
+		}
+		if builder["59"].(*Primitive).Value != "José" {
+			t.Error("expected builder values to be kept")
+		}
+	})
+
+	t.Run("build should reject invalid UTF-8", func(t *testing.T) {
+		builder := Builder{}
+		builder.AddMerchantName("Jos\xe9")
+		if _, err := builder.Build(); !errors.Is(err, ErrInvalidCharset) {
+			t.Errorf("expected ErrInvalidCharset but got: %v", err)
+		}
+	})
+
+	t.Run("parser should apply the charset policy", func(t *testing.T) {
+		qr := NewStatic("maria@email.com", "José", "São Paulo", "abc123")
+		code, err := qr.BRCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := NewParser()
+		static, err := p.ParseStatic(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if static.MerchantName != "José" || static.MerchantCity != "São Paulo" {
+			t.Errorf("expected UTF-8 values but got %s, %s", static.MerchantName, static.MerchantCity)
+		}
+
+		p.Charset = CharsetASCII
+		if _, err := p.ParseStatic(code); !errors.Is(err, ErrInvalidCharset) {
+			t.Errorf("expected ErrInvalidCharset but got: %v", err)
+		}
+
+		p.Charset = CharsetTransliterate
+		static, err = p.ParseStatic(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if static.MerchantName != "Jose" || static.MerchantCity != "Sao Paulo" {
+			t.Errorf("expected transliterated values but got %s, %s", static.MerchantName, static.MerchantCity)
+		}
+
+		expected := []ParseWarning{{ID: "59", Reason: "transliterated"}, {ID: "60", Reason: "transliterated"}}
+		if len(p.Warnings) != len(expected) || p.Warnings[0] != expected[0] || p.Warnings[1] != expected[1] {
+			t.Errorf("expected warnings %v but got %v", expected, p.Warnings)
+		}
+		builder, err := p.Parse(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := builder.CheckCRC(); err != nil {
+			t.Errorf("expected transliterated builder to pass CheckCRC but got: %v", err)
+		}
+		brCode, err := builder.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyCRC(brCode); err != nil || strings.Contains(brCode, "São") {
+			t.Errorf("expected a valid transliterated code but got %s (%v)", brCode, err)
+		}
+	})
+
+	t.Run("static with transliterate charset should encode ASCII", func(t *testing.T) {
+		qr := NewStatic("maria@email.com", "José", "São Paulo", "abc123", WithCharset(CharsetTransliterate))
+		code, err := qr.BRCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewParser()
+		p.Charset = CharsetASCII
+		static, err := p.ParseStatic(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if static.MerchantName != "Jose" || static.MerchantCity != "Sao Paulo" {
+			t.Errorf("expected transliterated values but got %s, %s", static.MerchantName, static.MerchantCity)
+		}
+	})
+}
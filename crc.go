@@ -0,0 +1,87 @@
+package qrpix
+
+import "strings"
+
+// CRC16/CCITT-FALSE parameters used by the BR Code specification
+const (
+	crc16Poly = 0x1021
+	crc16Init = 0xFFFF
+)
+
+// Size of the encoded CRC field, "6304" followed by 4 hex digits
+const crcFieldSize = 8
+
+// Lookup tables for reading 8 bytes at a time. crc16Tables[0] is the usual
+// byte table, and crc16Tables[k] gives the checksum of a byte followed by k
+// zero bytes.
+var crc16Tables [8][256]uint16
+
+func init() {
+	for i := range crc16Tables[0] {
+		c := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if c&0x8000 != 0 {
+				c = c<<1 ^ crc16Poly
+			} else {
+				c <<= 1
+			}
+		}
+		crc16Tables[0][i] = c
+	}
+	for k := 1; k < len(crc16Tables); k++ {
+		for i, c := range crc16Tables[k-1] {
+			crc16Tables[k][i] = c<<8 ^ crc16Tables[0][byte(c>>8)]
+		}
+	}
+}
+
+// Returns the CRC16/CCITT-FALSE checksum of data (polynomial 0x1021,
+// initial value 0xFFFF, no reflection and no final xor).
+func CRC16(data []byte) uint16 {
+	return crc16(data)
+}
+
+// Same as CRC16, but also works on strings without copying them into a byte
+// slice
+func crc16[T string | []byte](data T) uint16 {
+	c := uint16(crc16Init)
+	t := &crc16Tables
+	i := 0
+	// The checksum is linear, so each of the 8 bytes is looked up on its own
+	// and combined, instead of waiting for the previous byte
+	for ; len(data)-i >= 8; i += 8 {
+		c ^= uint16(data[i])<<8 | uint16(data[i+1])
+		c = t[7][c>>8] ^ t[6][byte(c)] ^
+			t[5][data[i+2]] ^ t[4][data[i+3]] ^
+			t[3][data[i+4]] ^ t[2][data[i+5]] ^
+			t[1][data[i+6]] ^ t[0][data[i+7]]
+	}
+	for ; i < len(data); i++ {
+		c = c<<8 ^ t[0][byte(c>>8)^data[i]]
+	}
+	return c
+}
+
+const upperHex = "0123456789ABCDEF"
+
+// Formats the checksum as 4 uppercase hex digits
+func formatCRC16(c uint16) string {
+	b := [4]byte{
+		upperHex[c>>12],
+		upperHex[c>>8&0xF],
+		upperHex[c>>4&0xF],
+		upperHex[c&0xF],
+	}
+	return string(b[:])
+}
+
+// Appends the CRC field to the code written so far. The checksum covers
+// everything before it, including the "6304" prefix.
+func writeCRC16(sb *strings.Builder) {
+	sb.WriteString("6304")
+	c := crc16(sb.String())
+	sb.WriteByte(upperHex[c>>12])
+	sb.WriteByte(upperHex[c>>8&0xF])
+	sb.WriteByte(upperHex[c>>4&0xF])
+	sb.WriteByte(upperHex[c&0xF])
+}
@@ -0,0 +1,111 @@
+package qrpix
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCRC16(t *testing.T) {
+	cases := []struct {
+		data     string
+		expected uint16
+	}{
+		{data: "", expected: 0xFFFF},
+		{data: "A", expected: 0xB915},
+		{data: "123456789", expected: 0x29B1},
+		{data: exampleCode[:len(exampleCode)-4], expected: 0x1D3D},
+	}
+
+	for _, c := range cases {
+		t.Run(c.data, func(t *testing.T) {
+			if got := CRC16([]byte(c.data)); got != c.expected {
+				t.Errorf("expected %04X but got %04X", c.expected, got)
+			}
+			if got := crc16(c.data); got != c.expected {
+				t.Errorf("expected %04X but got %04X", c.expected, got)
+			}
+		})
+	}
+
+	t.Run("should match the bitwise checksum for every length", func(t *testing.T) {
+		data := []byte(exampleCode)
+		for n := 0; n <= len(data); n++ {
+			if got, expected := CRC16(data[:n]), bitwiseCRC16(data[:n]); got != expected {
+				t.Errorf("expected %04X for %d bytes but got %04X", expected, n, got)
+			}
+		}
+	})
+
+	t.Run("should format as 4 uppercase hex digits", func(t *testing.T) {
+		for _, c := range []struct {
+			value    uint16
+			expected string
+		}{
+			{value: 0, expected: "0000"},
+			{value: 0x0A1F, expected: "0A1F"},
+			{value: 0xFFFF, expected: "FFFF"},
+		} {
+			if got := formatCRC16(c.value); got != c.expected {
+				t.Errorf("expected %s but got %s", c.expected, got)
+			}
+		}
+	})
+
+	t.Run("should not allocate", func(t *testing.T) {
+		data := []byte(exampleCode)
+		allocs := testing.AllocsPerRun(100, func() {
+			CRC16(data)
+		})
+		if allocs != 0 {
+			t.Errorf("expected no allocations but got %v", allocs)
+		}
+	})
+}
+
+// Computes the checksum a bit at a time, without lookup tables
+func bitwiseCRC16(data []byte) uint16 {
+	c := uint16(crc16Init)
+	for _, b := range data {
+		c ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if c&0x8000 != 0 {
+				c = c<<1 ^ crc16Poly
+			} else {
+				c <<= 1
+			}
+		}
+	}
+	return c
+}
+
+// Benchmark results are stored here, so the compiler can't drop the work
+var (
+	sink       uint16
+	stringSink string
+)
+
+func BenchmarkCRC16(b *testing.B) {
+	data := []byte(exampleCode)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sink = CRC16(data)
+	}
+}
+
+func BenchmarkCRC16Large(b *testing.B) {
+	data := []byte(strings.Repeat(exampleCode, 64))
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sink = CRC16(data)
+	}
+}
+
+func BenchmarkComputeCRC16(b *testing.B) {
+	data := exampleCode[:len(exampleCode)-4]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stringSink = computeCRC16(data)
+	}
+}
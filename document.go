@@ -0,0 +1,105 @@
+package qrpix
+
+import (
+	"strings"
+)
+
+// Field of a parsed code, as received
+type Field struct {
+	ID string
+	// Value as received. For templates, the encoded template values.
+	Value string
+	// Field as received, including id and length
+	Raw string
+	// Byte offsets of Raw in the code
+	Start int
+	End   int
+	// Template values in wire order, nil for primitives
+	Children []Field
+}
+
+// Reports whether the field was parsed as a template
+func (f Field) IsTemplate() bool {
+	return f.Children != nil
+}
+
+// Parsed code that keeps fields in wire order with their raw spans, so codes
+// that aren't in canonical form can be verified, displayed and re-emitted
+// exactly as received.
+type Document struct {
+	// Top level fields in wire order
+	Fields []Field
+
+	code string
+}
+
+// Returns the code exactly as received
+func (d *Document) String() string {
+	return d.code
+}
+
+// Gets the first field with the given id. Template values are identified as
+// "parent-child", such as "26-01".
+func (d *Document) Get(id string) (Field, bool) {
+	parent, child, isValue := strings.Cut(id, "-")
+	for _, f := range d.Fields {
+		if f.ID != parent {
+			continue
+		}
+		if !isValue {
+			return f, true
+		}
+		for _, c := range f.Children {
+			if c.ID == child {
+				return c, true
+			}
+		}
+	}
+	return Field{}, false
+}
+
+// Verifies the CRC against the code as received
+func (d *Document) VerifyCRC() error {
+	return VerifyCRC(d.code)
+}
+
+// Reports whether the code is in canonical form, which means building the
+// document fields gives back the same code.
+func (d *Document) IsCanonical() bool {
+	code, err := d.Builder().Build()
+	return err == nil && code == d.code
+}
+
+// Converts the document to a builder. Field order is lost and, for duplicate
+// ids, the last field wins. Fields without metadata are kept as opaque values.
+func (d *Document) Builder() Builder {
+	return fieldsToBuilder(d.Fields)
+}
+
+func fieldsToBuilder(fields []Field) Builder {
+	b := Builder{}
+	for _, f := range fields {
+		_, err := GetFieldMetadata(f.ID)
+		unknown := err != nil
+
+		if !f.IsTemplate() {
+			b.Add(&Primitive{ID: f.ID, Value: f.Value, unknown: unknown})
+			continue
+		}
+
+		t := Template{
+			ID:      f.ID,
+			values:  map[string]Primitive{},
+			unknown: unknown,
+		}
+		for _, c := range f.Children {
+			if _, err := GetFieldMetadata(f.ID + "-" + c.ID); err != nil {
+				t.addUnknownValue(c.ID, c.Value)
+				continue
+			}
+			t.AddValue(c.ID, c.Value)
+		}
+		b.Add(t)
+	}
+	return b
+}
@@ -0,0 +1,108 @@
+package qrpix
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	// Valid code with fields out of canonical order
+	unsorted := Builder{}.addCRC16("5204000000020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-42665544000053039865802BR5913Fulano de Tal6008BRASILIA62070503***")
+
+	t.Run("parse document should keep wire order and raw spans", func(t *testing.T) {
+		p := NewParser()
+		doc, err := p.ParseDocument(unsorted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.String() != unsorted {
+			t.Errorf("expected %s but got %s", unsorted, doc.String())
+		}
+
+		expected := []string{"52", "00", "26", "53", "58", "59", "60", "62", "63"}
+		if len(doc.Fields) != len(expected) {
+			t.Fatalf("expected %v fields but got %v", len(expected), len(doc.Fields))
+		}
+		raw := ""
+		for i, f := range doc.Fields {
+			if f.ID != expected[i] {
+				t.Errorf("expected field %s at position %v but got %s", expected[i], i, f.ID)
+			}
+			if unsorted[f.Start:f.End] != f.Raw {
+				t.Errorf("expected raw span %s but got %s", unsorted[f.Start:f.End], f.Raw)
+			}
+			raw += f.Raw
+		}
+		if raw != unsorted {
+			t.Errorf("expected raw fields to re-emit %s but got %s", unsorted, raw)
+		}
+
+		chave, ok := doc.Get("26-01")
+		if !ok || chave.Value != "123e4567-e12b-12d1-a456-426655440000" {
+			t.Errorf("unexpected chave field: %+v", chave)
+		}
+		if unsorted[chave.Start:chave.End] != chave.Raw {
+			t.Errorf("expected template value span %s but got %s", unsorted[chave.Start:chave.End], chave.Raw)
+		}
+		if _, ok := doc.Get("26-05"); ok {
+			t.Error("expected missing template value to not be found")
+		}
+
+		if err := doc.VerifyCRC(); err != nil {
+			t.Errorf("unexpected error verifying crc: %v", err)
+		}
+		if doc.IsCanonical() {
+			t.Error("expected unsorted code to not be canonical")
+		}
+	})
+
+	t.Run("document builder should build the canonical code", func(t *testing.T) {
+		p := NewParser()
+		doc, err := p.ParseDocument(unsorted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := doc.Builder().Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		canonical, err := p.ParseDocument(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !canonical.IsCanonical() {
+			t.Errorf("expected rebuilt code %s to be canonical", code)
+		}
+		if _, err := p.ParseStatic(code); err != nil {
+			t.Errorf("unexpected error parsing rebuilt code: %v", err)
+		}
+	})
+
+	t.Run("parse document should verify the crc as received", func(t *testing.T) {
+		p := NewParser()
+		code := unsorted[:len(unsorted)-4] + "0000"
+		if _, err := p.ParseDocument(code); !errors.Is(err, ErrInvalidCRC) {
+			t.Errorf("expected ErrInvalidCRC but got: %v", err)
+		}
+	})
+
+	t.Run("lenient parse document should keep unknown fields", func(t *testing.T) {
+		code := Builder{}.addCRC16("7003abc000201" + "27230011com.example01041234")
+		p := NewParser()
+		if _, err := p.ParseDocument(code); !errors.Is(err, ErrFieldMetadataNotFound) {
+			t.Errorf("expected ErrFieldMetadataNotFound but got: %v", err)
+		}
+
+		p.Lenient = true
+		doc, err := p.ParseDocument(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Fields) != 4 || doc.Fields[0].ID != "70" || !doc.Fields[2].IsTemplate() {
+			t.Errorf("unexpected fields: %+v", doc.Fields)
+		}
+		if len(p.Warnings) != 2 {
+			t.Errorf("expected 2 warnings but got %v", p.Warnings)
+		}
+	})
+}
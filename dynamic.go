@@ -0,0 +1,144 @@
+package qrpix
+
+import (
+	"io"
+	"net/http"
+)
+
+const (
+	// Reference label used by dynamic codes, the transaction id is
+	// provided by the payload location instead.
+	DynamicReferenceLabel = "***"
+)
+
+type Dynamic struct {
+	// Payload location, without the https:// prefix
+	URL                  string `json:"url"`
+	MerchantCategoryCode string `json:"mechantCategoryCode"`
+	TransactionCurrency  string `json:"transactionCurrency"`
+	CountryCode          string `json:"countryCode"`
+	MerchantName         string `json:"merchantName"`
+	MerchantCity         string `json:"merchantCity"`
+	PostalCode           string `json:"postalCode"`
+
+	charset Charset
+}
+
+type DynamicOptFn func(*Dynamic)
+
+func NewDynamic(url, merchantName, merchantCity string, fns ...DynamicOptFn) *Dynamic {
+	qr := &Dynamic{
+		URL:                  url,
+		MerchantCategoryCode: defaultMerchantCategoryCode,
+		TransactionCurrency:  defaultTransactionCurrency,
+		CountryCode:          defaultCountryCode,
+		MerchantName:         merchantName,
+		MerchantCity:         merchantCity,
+	}
+	for _, fn := range fns {
+		fn(qr)
+	}
+	return qr
+}
+
+// Sets the charset policy applied to every value, defaults to CharsetUTF8
+func WithDynamicCharset(c Charset) DynamicOptFn {
+	return func(d *Dynamic) {
+		d.apply(WithCharset(c))
+	}
+}
+
+func WithDynamicPostalCode(postalCode string) DynamicOptFn {
+	return func(d *Dynamic) {
+		d.apply(WithPostalCode(postalCode))
+	}
+}
+
+func WithDynamicCountryCode(code string) DynamicOptFn {
+	return func(d *Dynamic) {
+		d.apply(WithCountryCode(code))
+	}
+}
+
+func WithDynamicMerchantCategoryCode(code string) DynamicOptFn {
+	return func(d *Dynamic) {
+		d.apply(WithMerchantCategoryCode(code))
+	}
+}
+
+// Returns a static code with the fields both codes share, so options and
+// encoding of those fields are implemented once.
+func (d Dynamic) shared() Static {
+	return Static{
+		MerchantCategoryCode: d.MerchantCategoryCode,
+		TransactionCurrency:  d.TransactionCurrency,
+		CountryCode:          d.CountryCode,
+		MerchantName:         d.MerchantName,
+		MerchantCity:         d.MerchantCity,
+		PostalCode:           d.PostalCode,
+		charset:              d.charset,
+	}
+}
+
+// Applies a static option to the shared fields
+func (d *Dynamic) apply(fn StaticOptFn) {
+	s := d.shared()
+	fn(&s)
+	d.MerchantCategoryCode = s.MerchantCategoryCode
+	d.TransactionCurrency = s.TransactionCurrency
+	d.CountryCode = s.CountryCode
+	d.MerchantName = s.MerchantName
+	d.MerchantCity = s.MerchantCity
+	d.PostalCode = s.PostalCode
+	d.charset = s.charset
+}
+
+// Builds the BRCode. Point of Initiation Method is always set to single use.
+func (d *Dynamic) BRCode() (string, error) {
+	primitives, err := d.shared().merchantPrimitives(
+		Primitive{ID: "00", Value: PayloadFormatIndicator},
+		Primitive{ID: "01", Value: PointOfInitiationMethodSingleUse},
+	)
+	if err != nil {
+		return "", err
+	}
+	templates := []Template{
+		newMerchantAccountInformation(PIXGui, "25", d.URL),
+		newAdditionalDataField(DynamicReferenceLabel),
+	}
+
+	return encodeTLVs(sortedTLVs(primitives, templates), d.charset, true, true)
+}
+
+// Creates and saves a QRCode in the specified path. Image format is PNG.
+func (d Dynamic) SaveFile(path string, opts ...RenderOptFn) error {
+	return saveCode(&d, path, opts)
+}
+
+// Encodes the QRCode as a PNG image
+func (d Dynamic) Encode(opts ...RenderOptFn) ([]byte, error) {
+	return encodeCode(&d, opts)
+}
+
+// Encodes the QRCode as a SVG image and writes it to w
+func (d Dynamic) EncodeSVG(w io.Writer, opts ...RenderOptFn) error {
+	return encodeCodeSVG(&d, w, opts)
+}
+
+// Writes the QRCode as text for terminals, using Unicode half blocks or
+// ASCII with WithASCII. Use WithInvert for dark terminals.
+func (d Dynamic) WriteTerminal(w io.Writer, opts ...RenderOptFn) error {
+	return writeCodeTerminal(&d, w, opts)
+}
+
+// Encodes and serves the QRCode image
+func (d Dynamic) Serve(w http.ResponseWriter, opts ...RenderOptFn) error {
+	return serveCode(&d, w, nil, opts)
+}
+
+// Encodes and serves the QRCode image, negotiating the format with the
+// request. The image is served as SVG when the Accept header prefers
+// image/svg+xml, and as PNG otherwise.
+func (d Dynamic) ServeRequest(w http.ResponseWriter, r *http.Request, opts ...RenderOptFn) error {
+	return serveCode(&d, w, r, opts)
+}
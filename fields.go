@@ -2,7 +2,7 @@ package qrpix
 
 import (
 	"errors"
-	"fmt"
+	"strings"
 )
 
 const (
@@ -10,11 +10,134 @@ const (
 	FieldTemplate  = "template"
 )
 
+const (
+	// Max size of any TLV value, since lengths are encoded with two digits
+	maxFieldSize = 99
+)
+
 var (
 	ErrFieldIsRequired       = errors.New("field is required")
 	ErrFieldMetadataNotFound = errors.New("field metadata for provided id not found")
+	ErrFieldAboveMax         = errors.New("field above max size")
+	ErrFieldBelowMin         = errors.New("field below min size")
+	ErrInvalidAmount         = errors.New("invalid transaction amount")
+	ErrInvalidFieldValue     = errors.New("field value is not allowed")
 )
 
+// Reason a field failed validation
+type FieldErrorReason string
+
+const (
+	// Required field has an empty value
+	ReasonRequired FieldErrorReason = "required"
+	// Required field is missing from the builder
+	ReasonNotPresent FieldErrorReason = "not present"
+	ReasonAboveMax   FieldErrorReason = "above max"
+	ReasonBelowMin   FieldErrorReason = "below min"
+	// Field id has no metadata
+	ReasonUnknownField   FieldErrorReason = "unknown field"
+	ReasonInvalidCharset FieldErrorReason = "invalid charset"
+	ReasonInvalidKey     FieldErrorReason = "invalid key"
+	ReasonInvalidAmount  FieldErrorReason = "invalid amount"
+	// Value isn't one of the values the field allows
+	ReasonInvalidValue FieldErrorReason = "invalid value"
+)
+
+// Error describing which field failed validation and why. It matches the
+// sentinel error of its reason with errors.Is, such as ErrFieldIsRequired.
+type FieldError struct {
+	// Field id, for template values this is the value id
+	ID string
+	// Template id for template values, empty otherwise
+	ParentID string
+	// Metadata name, empty for unknown fields
+	Name  string
+	Value string
+	// Size limit that was exceeded, zero for other reasons
+	Limit  int
+	Reason FieldErrorReason
+}
+
+// Returns the field id in the metadata format, "parent-child" for template values
+func (e *FieldError) Path() string {
+	if e.ParentID == "" {
+		return e.ID
+	}
+	return e.ParentID + "-" + e.ID
+}
+
+func (e *FieldError) Error() string {
+	name := e.Name
+	if name == "" {
+		name = e.Path()
+	}
+	switch e.Reason {
+	case ReasonRequired:
+		return "field is required: " + name
+	case ReasonNotPresent:
+		return "required field not present: " + name
+	case ReasonAboveMax:
+		return "limit above max for field: " + name
+	case ReasonBelowMin:
+		return "limit below min for field: " + name
+	case ReasonUnknownField:
+		return "field metadata for provided id not found: " + name
+	case ReasonInvalidCharset:
+		return "invalid charset for field: " + name
+	case ReasonInvalidKey:
+		return "invalid pix key for field: " + name
+	case ReasonInvalidAmount:
+		return "invalid amount for field: " + name
+	case ReasonInvalidValue:
+		return "value not allowed for field: " + name
+	default:
+		return "invalid field: " + name
+	}
+}
+
+func (e *FieldError) Unwrap() error {
+	switch e.Reason {
+	case ReasonRequired:
+		return ErrFieldIsRequired
+	case ReasonNotPresent:
+		return ErrRequiredFieldNotPresent
+	case ReasonAboveMax:
+		return ErrFieldAboveMax
+	case ReasonBelowMin:
+		return ErrFieldBelowMin
+	case ReasonUnknownField:
+		return ErrFieldMetadataNotFound
+	case ReasonInvalidCharset:
+		return ErrInvalidCharset
+	case ReasonInvalidKey:
+		return ErrInvalidKey
+	case ReasonInvalidAmount:
+		return ErrInvalidAmount
+	case ReasonInvalidValue:
+		return ErrInvalidFieldValue
+	default:
+		return nil
+	}
+}
+
+// Creates a field error for an id in the metadata format ("parent-child" for
+// template values). The name is taken from the metadata, if any.
+func newFieldError(id, value string, reason FieldErrorReason) *FieldError {
+	e := &FieldError{
+		ID:     id,
+		Value:  value,
+		Reason: reason,
+	}
+	if parent, child, ok := strings.Cut(id, "-"); ok {
+		e.ParentID = parent
+		e.ID = child
+	}
+	if meta, ok := lookupMetadata(id); ok {
+		e.Name = meta.Name
+	}
+	return e
+}
+
 var (
 	IDMetadata = map[string]Metadata{
 		"00": {
@@ -24,6 +147,13 @@ var (
 			Type:     FieldPrimitive,
 			Required: true,
 		},
+		"01": {
+			Name:     "Point of Initiation Method",
+			MinSize:  2,
+			MaxSize:  2,
+			Type:     FieldPrimitive,
+			Required: false,
+		},
 		"26": {
 			Name:     "Merchant Account Information",
 			MinSize:  5,
@@ -55,6 +185,12 @@ var (
 			Required: false,
 			Type:     FieldPrimitive,
 		},
+		"26-25": {
+			Name:     "URL",
+			MaxSize:  77,
+			Required: false,
+			Type:     FieldPrimitive,
+		},
 		"52": {
 			Name:     "Merchant Category Code",
 			MinSize:  4,
@@ -128,6 +264,45 @@ var (
 	}
 )
 
+const (
+	firstUnreservedTemplateID = 80
+	lastUnreservedTemplateID  = 99
+)
+
+// Metadata shared by the unreserved templates (80-99). Each template
+// carries its own GUI in value 00 followed by context specific values.
+var (
+	unreservedTemplateMetadata = Metadata{
+		Name:     "Unreserved Template",
+		MinSize:  5,
+		MaxSize:  99,
+		Type:     FieldTemplate,
+		Required: false,
+	}
+	unreservedGUIMetadata = Metadata{
+		Name:     "Globally Unique Identifier",
+		MinSize:  1,
+		MaxSize:  32,
+		Type:     FieldPrimitive,
+		Required: true,
+	}
+	contextSpecificMetadata = Metadata{
+		Name:     "Context Specific Data",
+		MinSize:  1,
+		MaxSize:  99,
+		Type:     FieldPrimitive,
+		Required: false,
+	}
+)
+
+func isUnreservedTemplateID(id string) bool {
+	if !isNumericID(id) {
+		return false
+	}
+	n := int(id[0]-'0')*10 + int(id[1]-'0')
+	return n >= firstUnreservedTemplateID && n <= lastUnreservedTemplateID
+}
+
 type Metadata struct {
 	Name     string
 	MaxSize  int
@@ -137,32 +312,169 @@ type Metadata struct {
 }
 
 func GetFieldMetadata(id string) (Metadata, error) {
-	meta, ok := IDMetadata[id]
+	meta, ok := lookupMetadata(id)
 	if !ok {
 		return Metadata{}, ErrFieldMetadataNotFound
 	}
 	return meta, nil
 }
 
-// Validates a field value based on the provided id metadata
+// Returns the metadata of the id, "parent-child" for template values
+func lookupMetadata(id string) (Metadata, bool) {
+	var meta *Metadata
+	if parent, child, nested := strings.Cut(id, "-"); nested {
+		meta = valueMetadata(parent, child)
+	} else {
+		meta = fieldMetadata(id)
+	}
+	if meta == nil {
+		return Metadata{}, false
+	}
+	return *meta, true
+}
+
+// Returns the metadata of a top level field, nil if there's none
+func fieldMetadata(id string) *Metadata {
+	if !isNumericID(id) {
+		return nil
+	}
+	n, _ := twoDigits(id)
+	return metadataIndex[n].meta
+}
+
+// Returns the metadata of a template value, nil if there's none. The
+// "parent-child" id isn't built, so parsing doesn't allocate.
+func valueMetadata(parent, id string) *Metadata {
+	if !isNumericID(parent) || !isNumericID(id) {
+		return nil
+	}
+	p, _ := twoDigits(parent)
+	values := metadataIndex[p].values
+	if values == nil {
+		return nil
+	}
+	n, _ := twoDigits(id)
+	return values[n].meta
+}
+
+// Metadata of a field, with the metadata of its values by id for templates
+type indexedMetadata struct {
+	meta   *Metadata
+	values *[100]indexedMetadata
+	// Sorted ids of the required values
+	required []string
+}
+
+// Metadata by numeric id, computed once from IDMetadata, so fields are looked
+// up without hashing or building ids. Unreserved templates are matched by
+// range, instead of listing every template and value in IDMetadata.
+var metadataIndex = indexMetadata()
+
+func indexMetadata() *[100]indexedMetadata {
+	index := new([100]indexedMetadata)
+
+	// Unreserved templates share their values, unless IDMetadata lists one
+	unreserved := new([100]indexedMetadata)
+	unreserved[0].meta = &unreservedGUIMetadata
+	for i := 1; i < len(unreserved); i++ {
+		unreserved[i].meta = &contextSpecificMetadata
+	}
+	for i := firstUnreservedTemplateID; i <= lastUnreservedTemplateID; i++ {
+		index[i] = indexedMetadata{meta: &unreservedTemplateMetadata, values: unreserved}
+	}
+
+	for id, meta := range IDMetadata {
+		meta := meta
+		parent, child, nested := strings.Cut(id, "-")
+		if !isNumericID(parent) || (nested && !isNumericID(child)) {
+			continue
+		}
+		p, _ := twoDigits(parent)
+		if !nested {
+			index[p].meta = &meta
+			continue
+		}
+		values := index[p].values
+		switch {
+		case values == nil:
+			values = new([100]indexedMetadata)
+		case values == unreserved:
+			copied := *unreserved
+			values = &copied
+		}
+		index[p].values = values
+		n, _ := twoDigits(child)
+		values[n].meta = &meta
+	}
+
+	for i := range index {
+		if index[i].values == nil {
+			continue
+		}
+		for n, value := range index[i].values {
+			if value.meta != nil && value.meta.Required {
+				index[i].required = append(index[i].required, fieldLengths[n])
+			}
+		}
+	}
+	return index
+}
+
+// Validates a field value based on the provided id metadata. Sizes are
+// counted in bytes, so non-ASCII characters count as more than one. The
+// transaction amount (54) must also be a decimal with two places, "10.50",
+// and the point of initiation method (01) either "11" or "12".
 func ValidateField(id, value string) error {
 	meta, err := GetFieldMetadata(id)
 	if err != nil {
-		return err
+		return newFieldError(id, value, ReasonUnknownField)
 	}
+	return checkValue(&meta, "", id, value)
+}
 
-	if !meta.Required && value == "" {
+// Checks the value of a field with known metadata. The parent id is empty for
+// top level fields.
+func checkValue[T string | []byte](meta *Metadata, parent, id string, value T) error {
+	if !meta.Required && len(value) == 0 {
 		return nil
 	}
-	if meta.Required && value == "" {
-		return ErrFieldIsRequired
+	if meta.Required && len(value) == 0 {
+		return newFieldError(joinID(parent, id), "", ReasonRequired)
 	}
 	if len(value) > meta.MaxSize {
-		return fmt.Errorf("limit above max for field: %s", meta.Name)
+		e := newFieldError(joinID(parent, id), string(value), ReasonAboveMax)
+		e.Limit = meta.MaxSize
+		return e
 	}
 	if len(value) < meta.MinSize {
-		return fmt.Errorf("limit below min for field: %s", meta.Name)
+		e := newFieldError(joinID(parent, id), string(value), ReasonBelowMin)
+		e.Limit = meta.MinSize
+		return e
+	}
+	if parent == "" && id == "54" && !isValidAmount(value) {
+		return newFieldError(id, string(value), ReasonInvalidAmount)
+	}
+	if parent == "" && id == "01" && string(value) != PointOfInitiationMethodReusable && string(value) != PointOfInitiationMethodSingleUse {
+		return newFieldError(id, string(value), ReasonInvalidValue)
+	}
+
+	return nil
+}
+
+// Returns the id in the metadata format, "parent-child" for template values
+func joinID(parent, id string) string {
+	if parent == "" {
+		return id
 	}
+	return parent + "-" + id
+}
 
+// Validates a field without metadata. Only the TLV length limit is checked.
+func validateUnknownField(id, value string) error {
+	if len(value) > maxFieldSize {
+		e := newFieldError(id, value, ReasonAboveMax)
+		e.Limit = maxFieldSize
+		return e
+	}
 	return nil
 }
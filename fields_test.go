@@ -2,6 +2,7 @@ package qrpix
 
 import (
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -13,6 +14,34 @@ func TestFields(t *testing.T) {
 		}
 	})
 
+	t.Run("get field metadata should match unreserved templates by range", func(t *testing.T) {
+		cases := []struct {
+			id   string
+			name string
+			ok   bool
+		}{
+			{id: "80", name: "Unreserved Template", ok: true},
+			{id: "99", name: "Unreserved Template", ok: true},
+			{id: "85-00", name: "Globally Unique Identifier", ok: true},
+			{id: "85-01", name: "Context Specific Data", ok: true},
+			{id: "99-99", name: "Context Specific Data", ok: true},
+			{id: "79", ok: false},
+			{id: "85-1", ok: false},
+			{id: "85-0a", ok: false},
+			{id: "8a-01", ok: false},
+		}
+		for _, c := range cases {
+			meta, err := GetFieldMetadata(c.id)
+			if c.ok != (err == nil) {
+				t.Errorf("%s: expected found %v but got error: %v", c.id, c.ok, err)
+				continue
+			}
+			if meta.Name != c.name {
+				t.Errorf("%s: expected name %q but got %q", c.id, c.name, meta.Name)
+			}
+		}
+	})
+
 	t.Run("validate field should return error for unknown ids", func(t *testing.T) {
 		err := ValidateField("invalid", "")
 		if !errors.Is(err, ErrFieldMetadataNotFound) {
@@ -26,4 +55,109 @@ func TestFields(t *testing.T) {
 			t.Errorf("expected ErrFieldIsRequired but got: %v", err)
 		}
 	})
+
+	t.Run("validate field should return field errors", func(t *testing.T) {
+		cases := []struct {
+			id       string
+			value    string
+			expected FieldError
+			sentinel error
+		}{
+			{
+				id: "26-01", value: strings.Repeat("a", 78),
+				expected: FieldError{ID: "01", ParentID: "26", Name: "Chave", Value: strings.Repeat("a", 78), Limit: 77, Reason: ReasonAboveMax},
+				sentinel: ErrFieldAboveMax,
+			},
+			{
+				id: "52", value: "000",
+				expected: FieldError{ID: "52", Name: "Merchant Category Code", Value: "000", Limit: 4, Reason: ReasonBelowMin},
+				sentinel: ErrFieldBelowMin,
+			},
+			{
+				id: "59", value: "",
+				expected: FieldError{ID: "59", Name: "Merchant Name", Reason: ReasonRequired},
+				sentinel: ErrFieldIsRequired,
+			},
+			{
+				id: "26-04", value: "a",
+				expected: FieldError{ID: "04", ParentID: "26", Value: "a", Reason: ReasonUnknownField},
+				sentinel: ErrFieldMetadataNotFound,
+			},
+			{
+				id: "54", value: "10.5",
+				expected: FieldError{ID: "54", Name: "Transaction Amount", Value: "10.5", Reason: ReasonInvalidAmount},
+				sentinel: ErrInvalidAmount,
+			},
+		}
+		for _, c := range cases {
+			err := ValidateField(c.id, c.value)
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Errorf("expected FieldError for %s but got: %v", c.id, err)
+				continue
+			}
+			if *fieldErr != c.expected {
+				t.Errorf("expected %+v but got %+v", c.expected, *fieldErr)
+			}
+			if !errors.Is(err, c.sentinel) {
+				t.Errorf("expected error to match %v", c.sentinel)
+			}
+		}
+	})
+
+	t.Run("validate field should enforce the amount grammar", func(t *testing.T) {
+		for _, value := range []string{"0.01", "10.50", "9999999999.99"} {
+			if err := ValidateField("54", value); err != nil {
+				t.Errorf("unexpected error for %s: %v", value, err)
+			}
+		}
+		for _, value := range []string{"10", "10.5", "10.123", "1e3", "-5.00", "NaN", "+1.00", "1,00", ".50", "10.", "1.0a"} {
+			if err := ValidateField("54", value); !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("expected ErrInvalidAmount for %s but got: %v", value, err)
+			}
+		}
+	})
+
+	t.Run("template values should return field errors with parent id", func(t *testing.T) {
+		template := Template{ID: "26"}
+		template.AddValue("00", "")
+		_, err := template.Code()
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("expected FieldError but got: %v", err)
+		}
+		if fieldErr.Path() != "26-00" || fieldErr.Reason != ReasonRequired {
+			t.Errorf("expected required error for 26-00 but got %+v", fieldErr)
+		}
+	})
+
+	t.Run("builder getters should return field errors for missing fields", func(t *testing.T) {
+		builder := Builder{}
+		_, err := builder.GetMerchantCity()
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("expected FieldError but got: %v", err)
+		}
+		if fieldErr.ID != "60" || fieldErr.Reason != ReasonNotPresent {
+			t.Errorf("expected not present error for 60 but got %+v", fieldErr)
+		}
+		if !errors.Is(err, ErrRequiredFieldNotPresent) {
+			t.Error("expected error to match ErrRequiredFieldNotPresent")
+		}
+		if err.Error() != "required field not present: Merchant City" {
+			t.Errorf("unexpected error message: %s", err)
+		}
+	})
+
+	t.Run("parser should return field errors for unknown fields", func(t *testing.T) {
+		p := NewParser()
+		_, err := p.Parse("7003abc")
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("expected FieldError but got: %v", err)
+		}
+		if fieldErr.ID != "70" || fieldErr.Reason != ReasonUnknownField {
+			t.Errorf("expected unknown field error for 70 but got %+v", fieldErr)
+		}
+	})
 }
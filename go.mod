@@ -2,9 +2,6 @@ module github.com/ffss92/qrpix
 
 go 1.20
 
-require (
-	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
-	github.com/snksoft/crc v1.1.0
-)
+require github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 
 require golang.org/x/exp v0.0.0-20230522175609-2e198f4a06a1
@@ -0,0 +1,306 @@
+package qrpix
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid pix key")
+)
+
+// Type of a Pix key (chave)
+type KeyType int
+
+const (
+	KeyTypeUnknown KeyType = iota
+	KeyTypeCPF
+	KeyTypeCNPJ
+	KeyTypeEmail
+	KeyTypePhone
+	KeyTypeEVP
+)
+
+func (k KeyType) String() string {
+	switch k {
+	case KeyTypeCPF:
+		return "CPF"
+	case KeyTypeCNPJ:
+		return "CNPJ"
+	case KeyTypeEmail:
+		return "Email"
+	case KeyTypePhone:
+		return "Phone"
+	case KeyTypeEVP:
+		return "EVP"
+	default:
+		return "Unknown"
+	}
+}
+
+// Detects the key type based on its shape. The key is not validated,
+// use ValidateKey for that.
+func DetectKeyType(chave string) KeyType {
+	switch {
+	case chave == "":
+		return KeyTypeUnknown
+	case strings.HasPrefix(chave, "+"):
+		return KeyTypePhone
+	case strings.Contains(chave, "@"):
+		return KeyTypeEmail
+	case len(chave) == 11 && isDigits(chave):
+		return KeyTypeCPF
+	case len(chave) == 14 && isDigits(chave):
+		return KeyTypeCNPJ
+	case len(chave) == 36 && strings.Count(chave, "-") == 4:
+		return KeyTypeEVP
+	default:
+		return KeyTypeUnknown
+	}
+}
+
+// Validates the key according to its detected type: check digits for CPF
+// and CNPJ, +55 E.164 format for phones, address syntax for emails and the
+// UUID v4 shape for random keys (EVP).
+func ValidateKey(chave string) error {
+	keyType := DetectKeyType(chave)
+
+	var valid bool
+	switch keyType {
+	case KeyTypeCPF:
+		valid = isValidCPF(chave)
+	case KeyTypeCNPJ:
+		valid = isValidCNPJ(chave)
+	case KeyTypeEmail:
+		valid = isValidEmail(chave)
+	case KeyTypePhone:
+		valid = isValidPhone(chave)
+	case KeyTypeEVP:
+		valid = isValidEVP(chave)
+	default:
+		return fmt.Errorf("%w: unknown key type", ErrInvalidKey)
+	}
+
+	if !valid {
+		return fmt.Errorf("%w: malformed %s", ErrInvalidKey, keyType)
+	}
+	return nil
+}
+
+// Normalizes a key to the canonical form expected by banks: whitespace is
+// trimmed, CPF and CNPJ punctuation is removed, emails and random keys are
+// lowercased and brazilian phones are converted to the +55 form. Keys that
+// can't be recognized are only trimmed.
+func NormalizeKey(chave string) string {
+	chave = strings.TrimSpace(chave)
+
+	switch {
+	case strings.Contains(chave, "@"):
+		return strings.ToLower(chave)
+	case len(chave) == 36 && strings.Count(chave, "-") == 4:
+		return strings.ToLower(chave)
+	case isFormattedDocument(chave, "###.###.###-##"):
+		return stripNonDigits(chave)
+	case isFormattedDocument(chave, "##.###.###/####-##"):
+		return stripNonDigits(chave)
+	case isDigits(chave) && (len(chave) == 11 || len(chave) == 14):
+		// Bare digits are a CPF or CNPJ, phones must be formatted or prefixed
+		return chave
+	}
+
+	return normalizePhone(chave)
+}
+
+// Reports whether the value matches the layout, where # stands for a digit
+func isFormattedDocument(value, layout string) bool {
+	if len(value) != len(layout) {
+		return false
+	}
+	for i := 0; i < len(layout); i++ {
+		if layout[i] == '#' {
+			if value[i] < '0' || value[i] > '9' {
+				return false
+			}
+		} else if value[i] != layout[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func stripNonDigits(s string) string {
+	b := strings.Builder{}
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Converts phones like "(11) 99999-9999" or "+55 11 99999-9999" to
+// "+5511999999999". Only values with a phone signal are converted: a leading
+// +, parentheses or an area code followed by the number groups. Other digit
+// groups are returned as a CPF when the check digits are valid, so a pasted
+// CPF doesn't become a different key, and as is otherwise.
+func normalizePhone(phone string) string {
+	for _, r := range phone {
+		if !strings.ContainsRune("0123456789+()- .", r) {
+			return phone
+		}
+	}
+
+	digits := stripNonDigits(phone)
+	if !hasPhoneSignal(phone) {
+		if isValidCPF(digits) {
+			return digits
+		}
+		return phone
+	}
+
+	switch {
+	case strings.HasPrefix(phone, "+"):
+		return "+" + digits
+	case len(digits) == 10 || len(digits) == 11:
+		return "+55" + digits
+	case strings.HasPrefix(digits, "55") && (len(digits) == 12 || len(digits) == 13):
+		return "+" + digits
+	default:
+		return phone
+	}
+}
+
+// Reports whether the value is written as a phone: with a leading +,
+// parentheses, or as an area code followed by a 4-5 digit group and a 4 digit
+// group (or the whole 8-9 digit number), optionally after the 55 country code.
+func hasPhoneSignal(phone string) bool {
+	if strings.HasPrefix(phone, "+") || strings.ContainsAny(phone, "()") {
+		return true
+	}
+	groups := strings.FieldsFunc(phone, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '.'
+	})
+	if isPhoneGroups(groups) {
+		return true
+	}
+	// 55 is also an area code, so it's only taken as the country code when
+	// the remaining groups are a phone on their own
+	return len(groups) > 0 && groups[0] == "55" && isPhoneGroups(groups[1:])
+}
+
+// Reports whether the groups are an area code followed by the number
+func isPhoneGroups(groups []string) bool {
+	switch {
+	case len(groups) == 3:
+		return len(groups[0]) == 2 &&
+			(len(groups[1]) == 4 || len(groups[1]) == 5) &&
+			len(groups[2]) == 4
+	case len(groups) == 2:
+		return len(groups[0]) == 2 && (len(groups[1]) == 8 || len(groups[1]) == 9)
+	default:
+		return false
+	}
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isRepeated(s string) bool {
+	return strings.Count(s, s[:1]) == len(s)
+}
+
+// Computes a modulo 11 check digit for the given digits and weights
+func checkDigit(digits string, weights []int) byte {
+	sum := 0
+	for i, w := range weights {
+		sum += int(digits[i]-'0') * w
+	}
+	r := sum % 11
+	if r < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - r)
+}
+
+func isValidCPF(cpf string) bool {
+	if len(cpf) != 11 || !isDigits(cpf) || isRepeated(cpf) {
+		return false
+	}
+	first := checkDigit(cpf, []int{10, 9, 8, 7, 6, 5, 4, 3, 2})
+	second := checkDigit(cpf, []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2})
+	return cpf[9] == first && cpf[10] == second
+}
+
+func isValidCNPJ(cnpj string) bool {
+	if len(cnpj) != 14 || !isDigits(cnpj) || isRepeated(cnpj) {
+		return false
+	}
+	first := checkDigit(cnpj, []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+	second := checkDigit(cnpj, []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+	return cnpj[12] == first && cnpj[13] == second
+}
+
+// Email keys are limited to 77 characters and must be a bare address
+func isValidEmail(email string) bool {
+	if len(email) > 77 {
+		return false
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return false
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	return strings.Contains(domain, ".")
+}
+
+// Phone keys must be +55, followed by a two digit area code and an 8 digit
+// landline or 9 digit mobile number.
+func isValidPhone(phone string) bool {
+	number, ok := strings.CutPrefix(phone, "+55")
+	if !ok || !isDigits(number) {
+		return false
+	}
+	if len(number) != 10 && len(number) != 11 {
+		return false
+	}
+	if number[0] == '0' || number[1] == '0' {
+		return false
+	}
+	return len(number) == 10 || number[2] == '9'
+}
+
+// Random keys are lowercase UUIDs with version 4 and RFC 4122 variant
+func isValidEVP(evp string) bool {
+	if len(evp) != 36 {
+		return false
+	}
+	for i, r := range evp {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		case 14:
+			if r != '4' {
+				return false
+			}
+		case 19:
+			if r != '8' && r != '9' && r != 'a' && r != 'b' {
+				return false
+			}
+		default:
+			if !(r >= '0' && r <= '9') && !(r >= 'a' && r <= 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
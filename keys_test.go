@@ -0,0 +1,124 @@
+package qrpix
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	t.Run("detect key type should identify the key shape", func(t *testing.T) {
+		cases := []struct {
+			chave    string
+			expected KeyType
+		}{
+			{chave: "52998224725", expected: KeyTypeCPF},
+			{chave: "11222333000181", expected: KeyTypeCNPJ},
+			{chave: "maria@email.com", expected: KeyTypeEmail},
+			{chave: "+5511999999999", expected: KeyTypePhone},
+			{chave: "123e4567-e12b-42d1-a456-426655440000", expected: KeyTypeEVP},
+			{chave: "", expected: KeyTypeUnknown},
+			{chave: "12345", expected: KeyTypeUnknown},
+		}
+		for _, c := range cases {
+			if got := DetectKeyType(c.chave); got != c.expected {
+				t.Errorf("expected %s for %q but got %s", c.expected, c.chave, got)
+			}
+		}
+	})
+
+	t.Run("validate key should accept valid keys", func(t *testing.T) {
+		cases := []string{
+			"52998224725",
+			"11222333000181",
+			"maria@email.com",
+			"+5511999999999",
+			"+551133334444",
+			"123e4567-e12b-42d1-a456-426655440000",
+		}
+		for _, c := range cases {
+			if err := ValidateKey(c); err != nil {
+				t.Errorf("unexpected error for %q: %v", c, err)
+			}
+		}
+	})
+
+	t.Run("validate key should reject invalid keys", func(t *testing.T) {
+		cases := []string{
+			"",
+			"52998224724",                          // CPF check digit
+			"00000000000",                          // CPF repeated digits
+			"11222333000182",                       // CNPJ check digit
+			"maria@",                               // Email without domain
+			"Maria <maria@email.com>",              // Email with name
+			"+1555123456",                          // Phone outside Brazil
+			"+5511899999999",                       // Mobile not starting with 9
+			"+55119999",                            // Phone too short
+			"123e4567-e12b-12d1-a456-426655440000", // UUID v1
+			"123E4567-E12B-42D1-A456-426655440000", // Uppercase UUID
+		}
+		for _, c := range cases {
+			if err := ValidateKey(c); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("expected ErrInvalidKey for %q but got: %v", c, err)
+			}
+		}
+	})
+
+	t.Run("static with invalid key should fail to build", func(t *testing.T) {
+		qr := NewStatic("52998224724", "Fulano", "SAO PAULO", "abc123")
+		if _, err := qr.BRCode(); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("expected ErrInvalidKey but got: %v", err)
+		}
+	})
+
+	t.Run("normalize key should return the canonical form", func(t *testing.T) {
+		cases := []struct {
+			chave    string
+			expected string
+		}{
+			{chave: "529.982.247-25", expected: "52998224725"},
+			{chave: " 52998224725 ", expected: "52998224725"},
+			{chave: "11.222.333/0001-81", expected: "11222333000181"},
+			{chave: "(11) 99999-9999", expected: "+5511999999999"},
+			{chave: "11 3333-4444", expected: "+551133334444"},
+			{chave: "+55 (11) 99999-9999", expected: "+5511999999999"},
+			{chave: "55 11 99999 9999", expected: "+5511999999999"},
+			{chave: "Maria@Email.com ", expected: "maria@email.com"},
+			{chave: "123E4567-E12B-42D1-A456-426655440000", expected: "123e4567-e12b-42d1-a456-426655440000"},
+			{chave: "not a key", expected: "not a key"},
+			{chave: "529982247-25", expected: "52998224725"},
+			{chave: "529 982 247 25", expected: "52998224725"},
+			{chave: "123 456 789 01", expected: "123 456 789 01"},
+			{chave: "1234567-8901", expected: "1234567-8901"},
+			{chave: "11 999999999", expected: "+5511999999999"},
+			{chave: "55 9999-9999", expected: "+555599999999"},
+		}
+		for _, c := range cases {
+			if got := NormalizeKey(c.chave); got != c.expected {
+				t.Errorf("expected %q for %q but got %q", c.expected, c.chave, got)
+			}
+		}
+	})
+
+	t.Run("static with key normalization should encode the canonical key", func(t *testing.T) {
+		qr := NewStatic("(11) 99999-9999", "Fulano", "SAO PAULO", "abc123", WithKeyNormalization())
+		brCode, err := qr.BRCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewParser()
+		static, err := p.ParseStatic(brCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if static.Chave != "+5511999999999" {
+			t.Errorf("expected chave to be +5511999999999 but got %s", static.Chave)
+		}
+		if qr.Chave != "(11) 99999-9999" {
+			t.Errorf("expected original chave to be kept but got %s", qr.Chave)
+		}
+
+		if _, err := NewStatic("(11) 99999-9999", "Fulano", "SAO PAULO", "abc123").BRCode(); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("expected ErrInvalidKey without normalization but got: %v", err)
+		}
+	})
+}
@@ -7,89 +7,502 @@ import (
 )
 
 var (
-	ErrEmptyCode = errors.New("cannot parse empty code")
+	ErrEmptyCode        = errors.New("cannot parse empty code")
+	ErrInvalidFieldID   = errors.New("field id is not numeric")
+	ErrUnexpectedEnd    = errors.New("seek size exceeds rest of string")
+	ErrInvalidLength    = errors.New("field length is not numeric")
+	ErrTemplateOverflow = errors.New("template value exceeds template length")
+	ErrEmptyValue       = errors.New("field value is empty")
+	ErrDuplicateField   = errors.New("duplicate field id")
+	ErrFieldOrder       = errors.New("fields are not sorted by id")
+	ErrFieldAfterCRC    = errors.New("crc must be the last field")
 )
 
+// Number of bytes around the error offset included in ParseError.Snippet
+const snippetRadius = 10
+
+// Error describing where in the input the parser failed
+type ParseError struct {
+	// Byte offset of the input where the error was detected
+	Offset int
+	// Field being read, "parent-child" for template values. Empty when
+	// reading the id of a top level field.
+	Path string
+	// Bytes the parser expected to read and bytes left in the input, or in
+	// the enclosing template when reading template values
+	Expected  int
+	Remaining int
+	// Input surrounding the offset
+	Snippet string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	field := e.Path
+	if field == "" {
+		field = "-"
+	}
+	return fmt.Sprintf("%v at offset %d (field %s, expected %d bytes, %d remaining): %q",
+		e.Err, e.Offset, field, e.Expected, e.Remaining, e.Snippet)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Describes a field kept without validation by a lenient parser
+type ParseWarning struct {
+	// Field id, template values are identified as "parent-child"
+	ID     string
+	Reason string
+}
+
+func (w ParseWarning) String() string {
+	return w.ID + ": " + w.Reason
+}
+
+// Parser holds the settings used to read codes. Each call keeps its own
+// state, so a Parser can be shared across goroutines unless it writes
+// Warnings, which lenient and transliterating parses do.
 type Parser struct {
+	// Deprecated: the code is passed to each method and no longer kept in
+	// the parser.
 	Code string
-	cur  int
+	// When set, fields without metadata are kept as opaque primitives or
+	// templates and reported in Warnings instead of failing the parse.
+	Lenient bool
+	// Warnings of the last parse, only populated when Lenient is set or
+	// values are transliterated
+	Warnings []ParseWarning
+	// Charset policy applied to every value. With CharsetTransliterate the
+	// CRC is checked against the original values, which are transliterated
+	// afterwards. Changed values are reported in Warnings and the builder
+	// gets a CRC matching them.
+	Charset Charset
+	// Skips the CRC check, so the contents of codes with a missing or
+	// corrupted CRC can be read. Fields are still validated.
+	SkipCRC bool
+	// Accepts CRCs with lowercase hex digits
+	AcceptLowercaseCRC bool
+	// Accepts transaction amounts without two decimal places, such as "10"
+	// or "10.5", and normalizes them to "10.00" and "10.50". The CRC is
+	// checked against the amount as received, so the parsed code is no
+	// longer built back exactly as received.
+	NormalizeAmount bool
 }
 
 func NewParser() *Parser {
 	return &Parser{}
 }
 
-// Parses the BRCode into TLVs and returns a builder
+// Parses the BRCode with a parser using the default settings. It's safe for
+// concurrent use.
+func Parse(brCode string) (Builder, error) {
+	b, _, err := Parser{}.parse(brCode)
+	return b, err
+}
+
+// Reports whether the code would be accepted by Parse with the default
+// settings. The code is read in place by the same parser, without building
+// the TLVs, so valid codes are checked without allocating. It's safe for
+// concurrent use.
+func ValidateCode(code []byte) error {
+	s := parseState[[]byte]{code: code, canonical: true}
+	if _, err := s.parseFields(); err != nil {
+		return err
+	}
+	return s.checkCRC(false)
+}
+
+// Parses the BRCode into TLVs and returns a builder. The code must be in
+// canonical form (fields sorted by id, without duplicates and with the CRC
+// last), so it's built back exactly as received. Use ParseDocument for codes
+// in other forms.
 func (p *Parser) Parse(brCode string) (Builder, error) {
-	p.cur = 0 // Reset cursor
-	p.Code = brCode
-	parts := Builder{} // Reset parts
+	b, warnings, err := p.parse(brCode)
+	p.setWarnings(warnings)
+	return b, err
+}
 
-	if p.Code == "" {
-		return nil, ErrEmptyCode
+// Keeps the warnings of the last parse. Other parsers never have warnings,
+// so they aren't written and the parser can be shared.
+func (p *Parser) setWarnings(warnings []ParseWarning) {
+	if p.Lenient || p.Charset == CharsetTransliterate || p.Warnings != nil {
+		p.Warnings = warnings
 	}
+}
 
-	for p.cur != len(p.Code) {
-		id, err := p.readID()
+func (p Parser) parse(brCode string) (Builder, []ParseWarning, error) {
+	s := parseState[string]{
+		code:      brCode,
+		canonical: true,
+		collect:   true,
+		lenient:   p.Lenient,
+		charset:   p.Charset,
+		// Checked after normalizing
+		skipAmount: p.NormalizeAmount,
+	}
+	fields, err := s.parseFields()
+	if err != nil {
+		return nil, s.warnings, err
+	}
+	if !p.SkipCRC {
+		if err := s.checkCRC(p.AcceptLowercaseCRC); err != nil {
+			return nil, s.warnings, err
+		}
+	}
+
+	if p.NormalizeAmount {
+		for i := range fields {
+			if fields[i].ID != "54" {
+				continue
+			}
+			fields[i].Value = normalizeAmount(fields[i].Value)
+			if err := ValidateField("54", fields[i].Value); err != nil {
+				return nil, s.warnings, err
+			}
+		}
+	}
+
+	var changed []string
+	if p.Charset == CharsetTransliterate {
+		if changed, err = transliterateFields(fields); err != nil {
+			return nil, s.warnings, err
+		}
+		for _, id := range changed {
+			s.warn(id, "transliterated")
+		}
+	}
+
+	parts := fieldsToBuilder(fields)
+	if len(changed) > 0 {
+		// Transliterated values can change sizes, so they're validated again
+		raw, err := parts.buildRaw()
 		if err != nil {
+			return nil, s.warnings, err
+		}
+		// The received CRC was checked above, the builder gets the CRC of
+		// the transliterated values so it stays consistent
+		if _, ok := parts["63"]; ok {
+			parts.Add(&Primitive{ID: "63", Value: computeCRC16(raw + "6304")})
+		}
+	}
+
+	return parts, s.warnings, nil
+}
+
+// Parses the BRCode keeping fields in wire order with their raw spans. Field
+// order isn't checked and the CRC is verified against the code as received.
+func (p *Parser) ParseDocument(brCode string) (*Document, error) {
+	s := parseState[string]{
+		code:    brCode,
+		collect: true,
+		lenient: p.Lenient,
+		charset: p.Charset,
+	}
+	fields, err := s.parseFields()
+	p.setWarnings(s.warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.SkipCRC {
+		if err := verifyCRC(brCode, p.AcceptLowercaseCRC); err != nil {
 			return nil, err
 		}
+	}
 
-		meta, err := GetFieldMetadata(id)
+	return &Document{Fields: fields, code: brCode}, nil
+}
+
+// State of a single parse. Works on strings and bytes, so ValidateCode can
+// read codes in place, as do the generic helpers it calls to check values,
+// amounts, charsets and the CRC.
+type parseState[T string | []byte] struct {
+	code T
+	cur  int
+	// Template and field being read, used for error reporting. Both are
+	// empty when reading the id of a top level field.
+	parent, id string
+	// End of the template being read, zero when reading top level fields
+	end int
+	// Whether field order and values are checked, as Parse requires
+	canonical bool
+	// Whether fields are returned, ValidateCode only checks them
+	collect bool
+	lenient bool
+	charset Charset
+	// Skips the transaction amount check, for amounts normalized afterwards
+	skipAmount bool
+	warnings   []ParseWarning
+	// Id of the last top level field
+	last string
+}
+
+// Parses every top level field. In canonical mode, ids must be sorted
+// without duplicates and values are checked against their metadata. On
+// errors, the fields read before the broken one are returned.
+func (s *parseState[T]) parseFields() ([]Field, error) {
+	if len(s.code) == 0 {
+		return nil, ErrEmptyCode
+	}
+
+	var fields []Field
+	if s.collect {
+		fields = []Field{}
+	}
+	prev := ""
+	var field Field
+	for s.cur != len(s.code) {
+		start := s.cur
+		s.parent, s.id = "", ""
+		id, err := s.readID()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get metadata for id %s: %w", id, err)
+			return nil, err
 		}
+		if err := s.checkOrder(prev, id); err != nil {
+			return nil, err
+		}
+		prev = id
 
-		switch meta.Type {
-		case FieldPrimitive:
-			primitive := Primitive{
-				ID: id,
-			}
-			value, err := p.parsePrimitive(id)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse primitive with id %s: %w", id, err)
-			}
-			primitive.Value = value
-			parts.Add(&primitive)
-		case FieldTemplate:
-			template := Template{
-				ID:     id,
-				values: map[string]Primitive{},
-			}
-			if err := p.parseTemplate(id, &template); err != nil {
-				return nil, fmt.Errorf("failed to parse template with id %s: %w", id, err)
-			}
-			parts.Add(template)
+		field = Field{ID: id, Start: start}
+		if err := s.parseField(&field); err != nil {
+			// Fields read so far, so callers can tell where the code broke
+			return fields, err
+		}
+		if s.collect {
+			fields = append(fields, field)
 		}
 	}
+	s.last = prev
 
-	if err := parts.CheckCRC(); err != nil {
-		return nil, err
+	return fields, nil
+}
+
+// Parses the field whose id was read at its start. The field is filled in
+// place, since it's copied only when fields are collected.
+func (s *parseState[T]) parseField(field *Field) error {
+	id, start := field.ID, field.Start
+	meta := fieldMetadata(id)
+	if meta == nil && s.lenient {
+		unknown, err := s.parseUnknown(id, start)
+		if err != nil {
+			return fmt.Errorf("failed to parse unknown field with id %s: %w", id, err)
+		}
+		*field = unknown
+		return nil
+	}
+	if meta == nil {
+		return fmt.Errorf("failed to get metadata for id %s: %w", id, newFieldError(id, "", ReasonUnknownField))
 	}
 
-	return parts, nil
+	switch meta.Type {
+	case FieldPrimitive:
+		value, err := s.parsePrimitive("", id)
+		if err != nil {
+			return fmt.Errorf("failed to parse primitive with id %s: %w", id, err)
+		}
+		if err := s.checkValue(meta, "", id, value); err != nil {
+			return err
+		}
+		field.Value = s.str(value)
+	case FieldTemplate:
+		value, children, err := s.parseTemplate(id, false)
+		if err != nil {
+			return fmt.Errorf("failed to parse template with id %s: %w", id, err)
+		}
+		if err := s.checkValue(meta, "", id, value); err != nil {
+			return err
+		}
+		field.Value = s.str(value)
+		field.Children = children
+	}
+	field.End = s.cur
+	field.Raw = s.str(s.code[start:s.cur])
+	return nil
 }
 
-func (p *Parser) parseTemplate(id string, t *Template) error {
-	n, err := p.readLength()
+// Parses the values of a template. Values of unknown templates have no
+// metadata, so they aren't looked up or reported as warnings.
+func (s *parseState[T]) parseTemplate(id string, unknown bool) (T, []Field, error) {
+	var none T
+	s.parent, s.id = "", id
+	n, err := s.readLength()
 	if err != nil {
-		return err
+		return none, nil, err
+	}
+	if n == 0 {
+		return none, nil, s.newError(ErrEmptyValue, 0)
+	}
+	if err := s.checkSize(n); err != nil {
+		return none, nil, fmt.Errorf("failed to read template of size %v: %w", n, err)
+	}
+
+	// Values crossing the template end fail with ErrTemplateOverflow
+	valueStart := s.cur
+	s.end = s.cur + n
+	defer func() { s.end = 0 }()
+
+	var children []Field
+	if s.collect {
+		children = []Field{}
 	}
-	currPos := p.cur
-	for currPos+n != p.cur {
-		pid, err := p.readID()
+	// Values read, to check the required ones without allocating
+	var seen [maxFieldSize + 1]bool
+	prev := ""
+	for s.cur < s.end {
+		start := s.cur
+		s.parent, s.id = "", id
+		pid, err := s.readID()
 		if err != nil {
-			return fmt.Errorf("failed to read template value id: %w", err)
+			return none, nil, fmt.Errorf("failed to read template value id: %w", err)
 		}
-		value, err := p.parsePrimitive(id + "-" + pid)
+		if unknown && !isNumericID(pid) {
+			return none, nil, s.newError(ErrInvalidFieldID, 0)
+		}
+		if err := s.checkOrder(prev, pid); err != nil {
+			return none, nil, err
+		}
+		prev = pid
+		if n, ok := twoDigits(pid); ok {
+			seen[n] = true
+		}
+		value, err := s.parsePrimitive(id, pid)
 		if err != nil {
-			return fmt.Errorf("failed to parse template primitive with id %s: %w", pid, err)
+			return none, nil, fmt.Errorf("failed to parse template primitive with id %s: %w", pid, err)
+		}
+
+		if !unknown {
+			meta := valueMetadata(id, pid)
+			switch {
+			case meta != nil:
+				if err := s.checkValue(meta, id, pid, value); err != nil {
+					return none, nil, err
+				}
+			case !s.lenient:
+				return none, nil, fmt.Errorf("failed to get metadata for id %s-%s: %w", id, pid, newFieldError(id+"-"+pid, string(value), ReasonUnknownField))
+			default:
+				s.warn(id+"-"+pid, "unknown template value")
+			}
+		}
+		if s.collect {
+			children = append(children, Field{
+				ID:    pid,
+				Value: string(value),
+				Raw:   string(s.code[start:s.cur]),
+				Start: start,
+				End:   s.cur,
+			})
+		}
+	}
+
+	if s.canonical && !unknown {
+		for _, rid := range requiredValueIDs(id, seen[25]) {
+			if n, _ := twoDigits(rid); !seen[n] {
+				return none, nil, newFieldError(id+"-"+rid, "", ReasonNotPresent)
+			}
+		}
+	}
+	return s.code[valueStart:s.end], children, nil
+}
+
+// Parses a field without metadata. IDs reserved for templates are parsed as
+// templates when their value is a valid sequence of TLVs, otherwise the field
+// is kept as a primitive.
+func (s *parseState[T]) parseUnknown(id string, start int) (Field, error) {
+	if !isNumericID(id) {
+		return Field{}, s.newError(ErrInvalidFieldID, 0)
+	}
+
+	field := Field{ID: id, Start: start}
+	if isTemplateID(id) {
+		valueStart := s.cur
+		value, children, err := s.parseTemplate(id, true)
+		if err == nil {
+			s.warn(id, "unknown template")
+			field.Value = s.str(value)
+			field.Children = children
+			field.End = s.cur
+			field.Raw = s.str(s.code[start:s.cur])
+			return field, nil
 		}
-		t.AddValue(pid, value)
+		s.cur = valueStart // Retry as a primitive
+	}
+
+	value, err := s.parsePrimitive("", id)
+	if err != nil {
+		return Field{}, err
+	}
+	s.warn(id, "unknown primitive")
+	field.Value = s.str(value)
+	field.End = s.cur
+	field.Raw = s.str(s.code[start:s.cur])
+	return field, nil
+}
+
+// Checks the value against its metadata in canonical mode, so codes accepted
+// by Parse are built back without errors.
+func (s *parseState[T]) checkValue(meta *Metadata, parent, id string, value T) error {
+	if !s.canonical || (s.skipAmount && parent == "" && id == "54") {
+		return nil
+	}
+	return checkValue(meta, parent, id, value)
+}
+
+// Checks that ids are sorted without duplicates, so the parsed code is built
+// back exactly as received. The CRC (63) must be the last top level field.
+func (s *parseState[T]) checkOrder(prev, id string) error {
+	switch {
+	case !s.canonical || prev == "":
+		return nil
+	case prev == "63" && s.end == 0:
+		return s.newError(ErrFieldAfterCRC, 0)
+	case id == prev:
+		return s.newError(ErrDuplicateField, 0)
+	case id == "63" && s.end == 0:
+		return nil
+	case id < prev:
+		return s.newError(ErrFieldOrder, 0)
 	}
 	return nil
 }
 
+// Checks the CRC of the parsed code, which must be its last field
+func (s *parseState[T]) checkCRC(foldCase bool) error {
+	if s.last != "63" {
+		return ErrCRCNotPresent
+	}
+	return verifyCRC(s.code, foldCase)
+}
+
+func (s *parseState[T]) warn(id, reason string) {
+	s.warnings = append(s.warnings, ParseWarning{ID: id, Reason: reason})
+}
+
+// Converts the value for the returned fields, skipped when fields aren't
+// collected
+func (s *parseState[T]) str(value T) string {
+	if !s.collect {
+		return ""
+	}
+	return string(value)
+}
+
+func isNumericID(id string) bool {
+	return len(id) == 2 && id[0] >= '0' && id[0] <= '9' && id[1] >= '0' && id[1] <= '9'
+}
+
+// Reports whether the EMV specification reserves the id for templates:
+// merchant account information (26-51), additional data (62), language
+// template (64) and unreserved templates (80-99).
+func isTemplateID(id string) bool {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return false
+	}
+	return (n >= 26 && n <= 51) || n == 62 || n == 64 || n >= 80
+}
+
 func (p *Parser) ParseStatic(brCode string) (*Static, error) {
 	builder, err := p.Parse(brCode)
 	if err != nil {
@@ -98,6 +511,12 @@ func (p *Parser) ParseStatic(brCode string) (*Static, error) {
 
 	static := &Static{}
 
+	method, err := builder.GetPointOfInitiationMethod()
+	if err != nil {
+		return nil, err
+	}
+	static.PointOfInitiationMethod = method
+
 	chave, err := builder.GetMerchantAccountInformationChave()
 	if err != nil {
 		return nil, err
@@ -152,65 +571,177 @@ func (p *Parser) ParseStatic(brCode string) (*Static, error) {
 	}
 	static.MerchantName = merchName
 
+	templates, err := builder.GetUnreservedTemplates()
+	if err != nil {
+		return nil, err
+	}
+	static.UnreservedTemplates = templates
+
 	return static, nil
 }
 
-func (p *Parser) parsePrimitive(id string) (string, error) {
-	n, err := p.readLength()
+func (p *Parser) ParseDynamic(brCode string) (*Dynamic, error) {
+	builder, err := p.Parse(brCode)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamic := &Dynamic{}
+
+	url, err := builder.GetMerchantAccountInformationURL()
 	if err != nil {
-		return "", fmt.Errorf("failed to read primitive length: %w", err)
+		return nil, err
 	}
+	if url == "" {
+		return nil, newFieldError("26-25", "", ReasonNotPresent)
+	}
+	dynamic.URL = url
 
-	value, err := p.readValue(n)
+	countryCode, err := builder.GetCountryCode()
 	if err != nil {
-		return "", fmt.Errorf("failed to read primitive value: %w", err)
+		return nil, err
+	}
+	dynamic.CountryCode = countryCode
+
+	catCode, err := builder.GetMerchantCategoryCode()
+	if err != nil {
+		return nil, err
+	}
+	dynamic.MerchantCategoryCode = catCode
+
+	merchCity, err := builder.GetMerchantCity()
+	if err != nil {
+		return nil, err
+	}
+	dynamic.MerchantCity = merchCity
+
+	currency, err := builder.GetTransactionCurrency()
+	if err != nil {
+		return nil, err
+	}
+	dynamic.TransactionCurrency = currency
+
+	postalCode, err := builder.GetPostalCode()
+	if err != nil {
+		return nil, err
+	}
+	dynamic.PostalCode = postalCode
+
+	merchName, err := builder.GetMerchanName()
+	if err != nil {
+		return nil, err
+	}
+	dynamic.MerchantName = merchName
+
+	return dynamic, nil
+}
+
+func (s *parseState[T]) parsePrimitive(parent, id string) (T, error) {
+	var none T
+	s.parent, s.id = parent, id
+	n, err := s.readLength()
+	if err != nil {
+		return none, fmt.Errorf("failed to read primitive length: %w", err)
+	}
+	if n == 0 {
+		return none, s.newError(ErrEmptyValue, 0)
+	}
+
+	value, err := s.readValue(n)
+	if err != nil {
+		return none, fmt.Errorf("failed to read primitive value: %w", err)
+	}
+	if !inCharset(s.charset, value) {
+		return none, newFieldError(joinID(parent, id), string(value), ReasonInvalidCharset)
 	}
 
 	return value, nil
 }
 
-func (p *Parser) readID() (string, error) {
-	if err := p.checkSize(2); err != nil {
+func (s *parseState[T]) readID() (string, error) {
+	if err := s.checkSize(2); err != nil {
 		return "", fmt.Errorf("failed to read id: %w", err)
 	}
 
-	id := p.Code[p.cur : p.cur+2] // Move 2 chars
-	p.moveCursor(2)
-	return id, nil
+	raw := s.code[s.cur : s.cur+2] // Move 2 chars
+	s.moveCursor(2)
+	if n, ok := twoDigits(raw); ok {
+		// Shared strings, so ids don't allocate
+		return fieldLengths[n], nil
+	}
+	return string(raw), nil
 }
 
-func (p *Parser) readLength() (int, error) {
-	if err := p.checkSize(2); err != nil {
+func (s *parseState[T]) readLength() (int, error) {
+	if err := s.checkSize(2); err != nil {
 		return 0, fmt.Errorf("failed to read length: %w", err)
 	}
-	s := p.Code[p.cur : p.cur+2]
-
-	length, err := strconv.Atoi(s)
-	if err != nil {
-		return 0, fmt.Errorf("failed to convert length to int: %w", err)
+	length, ok := twoDigits(s.code[s.cur : s.cur+2])
+	if !ok {
+		return 0, fmt.Errorf("failed to convert length to int: %w", s.newError(ErrInvalidLength, 2))
 	}
 
-	p.moveCursor(2)
+	s.moveCursor(2)
 	return length, nil
 }
 
-func (p *Parser) readValue(n int) (string, error) {
-	if err := p.checkSize(n); err != nil {
-		return "", fmt.Errorf("failed to read value of size %v: %w", n, err)
+// Reads two decimal digits, without signs or spaces
+func twoDigits[T string | []byte](s T) (int, bool) {
+	if s[0] < '0' || s[0] > '9' || s[1] < '0' || s[1] > '9' {
+		return 0, false
+	}
+	return int(s[0]-'0')*10 + int(s[1]-'0'), true
+}
+
+func (s *parseState[T]) readValue(n int) (T, error) {
+	if err := s.checkSize(n); err != nil {
+		var none T
+		return none, fmt.Errorf("failed to read value of size %v: %w", n, err)
 	}
-	value := p.Code[p.cur : p.cur+n]
+	value := s.code[s.cur : s.cur+n]
 
-	p.moveCursor(n)
+	s.moveCursor(n)
 	return value, nil
 }
 
-func (p *Parser) checkSize(n int) error {
-	if len(p.Code[p.cur:]) < n {
-		return errors.New("seek size exceeds rest of string")
+func (s *parseState[T]) checkSize(n int) error {
+	if s.limit()-s.cur >= n {
+		return nil
+	}
+	if s.end > 0 && len(s.code)-s.cur >= n {
+		return s.newError(ErrTemplateOverflow, n)
+	}
+	return s.newError(ErrUnexpectedEnd, n)
+}
+
+// Returns the position reads can't cross: the end of the current template
+// or of the input.
+func (s *parseState[T]) limit() int {
+	if s.end > 0 {
+		return s.end
+	}
+	return len(s.code)
+}
+
+// Creates an error at the current cursor position
+func (s *parseState[T]) newError(err error, expected int) *ParseError {
+	from, to := s.cur-snippetRadius, s.cur+snippetRadius
+	if from < 0 {
+		from = 0
+	}
+	if to > len(s.code) {
+		to = len(s.code)
+	}
+	return &ParseError{
+		Offset:    s.cur,
+		Path:      joinID(s.parent, s.id),
+		Expected:  expected,
+		Remaining: s.limit() - s.cur,
+		Snippet:   string(s.code[from:to]),
+		Err:       err,
 	}
-	return nil
 }
 
-func (p *Parser) moveCursor(n int) {
-	p.cur += n
+func (s *parseState[T]) moveCursor(n int) {
+	s.cur += n
 }
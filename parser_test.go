@@ -2,16 +2,20 @@ package qrpix
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"testing"
 )
 
 func TestStaticEncodeDecode(t *testing.T) {
 	cases := []*Static{
-		NewStatic("00000000000", "Fulano", "SAO PAULO", "abc123"),
+		NewStatic("52998224725", "Fulano", "SAO PAULO", "abc123"),
 		NewStatic("+5599999999999", "José", "MANAUS", "sdf3dfs34e", WithTransactionAmount(1000)),
 		NewStatic("maria@email.com", "Maria", "OURO PRETO", "231dsad", WithTransactionAmount(1000), WithPostalCode("33400000")),
-		NewStatic("123e4567-e12b-12d1-a456-426655440000", "Maria", "BRASILIA", "sfdsdf", WithCountryCode("AR"), WithMerchantCategoryCode("9999")),
+		NewStatic("123e4567-e12b-42d1-a456-426655440000", "Maria", "BRASILIA", "sfdsdf", WithCountryCode("AR"), WithMerchantCategoryCode("9999")),
+		NewStatic("11222333000181", "Empresa", "RECIFE", "nf1234"),
 	}
 	for _, c := range cases {
 		brCode, err := c.BRCode()
@@ -56,8 +60,7 @@ func TestBaseParserMethods(t *testing.T) {
 			{code: "1"},
 		}
 		for _, c := range cases {
-			p := NewParser()
-			p.Code = c.code
+			p := parseState[string]{code: c.code}
 			if _, err := p.readLength(); err == nil {
 				t.Error("expected error but got nil")
 			}
@@ -74,8 +77,7 @@ func TestBaseParserMethods(t *testing.T) {
 			{code: "021"},
 		}
 		for _, c := range cases {
-			p := NewParser()
-			p.Code = c.code
+			p := parseState[string]{code: c.code}
 			length, err := p.readLength()
 			if err != nil {
 				t.Errorf("unexpected error reading length: %v", err)
@@ -87,25 +89,21 @@ func TestBaseParserMethods(t *testing.T) {
 	})
 
 	t.Run("checkSize should return error if cur + n exceeds Code length", func(t *testing.T) {
-		p := NewParser()
-		p.Code = "000201"
-		p.cur = 4
+		p := parseState[string]{code: "000201", cur: 4}
 		if err := p.checkSize(3); err == nil {
 			t.Error("expected error but got nil")
 		}
 	})
 
 	t.Run("checkSize should not return error if cur + n dont exceed Code length", func(t *testing.T) {
-		p := NewParser()
-		p.Code = "000201"
-		p.cur = 0
+		p := parseState[string]{code: "000201"}
 		if err := p.checkSize(3); err != nil {
 			t.Errorf("expected nil but got err: %v", err)
 		}
 	})
 
 	t.Run("move cursor should add n to the current cursor", func(t *testing.T) {
-		p := NewParser()
+		p := parseState[string]{}
 		steps := []int{4, 5, 2, 3}
 		expected := 0
 
@@ -131,8 +129,7 @@ func TestBaseParserMethods(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		p := Parser{}
-		p.Code = c.code
+		p := parseState[string]{code: c.code}
 
 		t.Run("readID should move cursor by 2 and get correct id for valid cases", func(t *testing.T) {
 			id, err := p.readID()
@@ -188,13 +185,12 @@ func TestPrimitiveParse(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		p := NewParser()
-		p.Code = c.code
+		p := parseState[string]{code: c.code}
 		id, err := p.readID()
 		if err != nil {
 			t.Error(err)
 		}
-		value, err := p.parsePrimitive(id)
+		value, err := p.parsePrimitive("", id)
 		if err != nil {
 			t.Error(err)
 		}
@@ -255,6 +251,23 @@ func TestParser(t *testing.T) {
 		log.Println(static)
 	})
 
+	t.Run("parser should be shared across goroutines", func(t *testing.T) {
+		p := &Parser{AcceptLowercaseCRC: true}
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, code := range bankCodes {
+					if _, err := p.ParseStatic(code); err != nil && !errors.Is(err, ErrRequiredFieldNotPresent) {
+						t.Error(err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
 	t.Run("invalid crc", func(t *testing.T) {
 		p := Parser{}
 		code := "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D4D"
@@ -290,3 +303,795 @@ func TestParser(t *testing.T) {
 	})
 
 }
+
+func TestDynamicEncodeDecode(t *testing.T) {
+	cases := []*Dynamic{
+		NewDynamic("pix.example.com/qr/v2/9d36b84fc70b478fb95c12729b90ca25", "Fulano", "SAO PAULO"),
+		NewDynamic("pix.example.com/qr/v2/cobv/1234", "Maria", "OURO PRETO", WithDynamicPostalCode("33400000")),
+		NewDynamic("pix.example.com/qr/v2/abc", "Maria", "BRASILIA", WithDynamicCountryCode("AR"), WithDynamicMerchantCategoryCode("9999")),
+	}
+	for _, c := range cases {
+		brCode, err := c.BRCode()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		p := NewParser()
+		builder, err := p.Parse(brCode)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if _, ok := builder["01"]; !ok {
+			t.Error("expected point of initiation method to be set")
+		}
+		label, err := builder.GetTransactionId()
+		if err != nil {
+			t.Error(err)
+		}
+		if label != DynamicReferenceLabel {
+			t.Errorf("expected reference label to be %s but got %s", DynamicReferenceLabel, label)
+		}
+
+		dynamic, err := p.ParseDynamic(brCode)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if *dynamic != *c {
+			t.Errorf("expected %+v but got %+v", c, dynamic)
+		}
+
+		if _, err := p.ParseStatic(brCode); !errors.Is(err, ErrRequiredFieldNotPresent) {
+			t.Errorf("expected ErrRequiredFieldNotPresent parsing dynamic as static but got: %v", err)
+		}
+	}
+
+	t.Run("empty url should return error", func(t *testing.T) {
+		_, err := NewDynamic("", "Fulano", "SAO PAULO").BRCode()
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Path() != "26-25" || fieldErr.Reason != ReasonNotPresent {
+			t.Errorf("expected 26-25 not to be present but got: %v", err)
+		}
+	})
+
+	t.Run("merchant account information requires a chave or url", func(t *testing.T) {
+		code := Builder{}.addCRC16("00020126180014br.gov.bcb.pix5204000053039865802BR5913Fulano de Tal6008BRASILIA")
+		_, err := Parse(code)
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Path() != "26-01" || fieldErr.Reason != ReasonNotPresent {
+			t.Errorf("expected 26-01 not to be present but got: %v", err)
+		}
+		if verr := ValidateCode([]byte(code)); verr == nil || verr.Error() != err.Error() {
+			t.Errorf("expected %v but got: %v", err, verr)
+		}
+
+		template := Template{ID: "26"}
+		template.AddValue("00", PIXGui)
+		if _, err := template.Code(); !errors.Is(err, ErrRequiredFieldNotPresent) {
+			t.Errorf("expected ErrRequiredFieldNotPresent but got: %v", err)
+		}
+		template.AddValue("25", "pix.example.com/qr/v2/1")
+		if _, err := template.Code(); err != nil {
+			t.Errorf("unexpected error for template with url: %v", err)
+		}
+	})
+}
+
+func TestPointOfInitiationMethod(t *testing.T) {
+	t.Run("code with point of initiation method should be parsed", func(t *testing.T) {
+		code := "00020101021126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***630448CD"
+		p := NewParser()
+		static, err := p.ParseStatic(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if static.PointOfInitiationMethod != PointOfInitiationMethodReusable {
+			t.Errorf("expected point of initiation method to be %s but got %s", PointOfInitiationMethodReusable, static.PointOfInitiationMethod)
+		}
+		if static.IsSingleUse() {
+			t.Error("expected code to be reusable")
+		}
+	})
+
+	t.Run("only reusable and single use methods should be accepted", func(t *testing.T) {
+		for _, value := range []string{PointOfInitiationMethodReusable, PointOfInitiationMethodSingleUse} {
+			if err := ValidateField("01", value); err != nil {
+				t.Errorf("unexpected error for %s: %v", value, err)
+			}
+		}
+		for _, value := range []string{"99", "10", "13"} {
+			err := ValidateField("01", value)
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Reason != ReasonInvalidValue || !errors.Is(err, ErrInvalidFieldValue) {
+				t.Errorf("expected invalid value error for %s but got: %v", value, err)
+			}
+		}
+
+		if _, err := NewStatic("maria@email.com", "Maria", "OURO PRETO", "231dsad", func(s *Static) {
+			s.PointOfInitiationMethod = "99"
+		}).BRCode(); !errors.Is(err, ErrInvalidFieldValue) {
+			t.Errorf("expected ErrInvalidFieldValue building method 99 but got: %v", err)
+		}
+		code := Builder{}.addCRC16("000201010299" + exampleCode[6:len(exampleCode)-8])
+		if _, err := Parse(code); !errors.Is(err, ErrInvalidFieldValue) {
+			t.Errorf("expected ErrInvalidFieldValue parsing method 99 but got: %v", err)
+		}
+	})
+
+	t.Run("single use option should round trip", func(t *testing.T) {
+		qr := NewStatic("maria@email.com", "Maria", "OURO PRETO", "231dsad", WithSingleUse())
+		brCode, err := qr.BRCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewParser()
+		static, err := p.ParseStatic(brCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !static.IsSingleUse() {
+			t.Errorf("expected code to be single use but got %q", static.PointOfInitiationMethod)
+		}
+	})
+
+	t.Run("missing point of initiation method should be empty", func(t *testing.T) {
+		p := NewParser()
+		static, err := p.ParseStatic(exampleCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if static.PointOfInitiationMethod != "" {
+			t.Errorf("expected empty point of initiation method but got %s", static.PointOfInitiationMethod)
+		}
+	})
+}
+
+func TestLenientParser(t *testing.T) {
+	code := Builder{}.addCRC16(
+		"000201" + "0204ABCD" +
+			"26580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-426655440000" +
+			"27230011com.example01041234" +
+			"5204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***" +
+			"7003abc",
+	)
+
+	t.Run("strict parser should fail for unknown fields", func(t *testing.T) {
+		p := NewParser()
+		if _, err := p.Parse(code); !errors.Is(err, ErrFieldMetadataNotFound) {
+			t.Errorf("expected ErrFieldMetadataNotFound but got: %v", err)
+		}
+	})
+
+	t.Run("lenient parser should keep unknown fields", func(t *testing.T) {
+		p := NewParser()
+		p.Lenient = true
+		builder, err := p.Parse(code)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []ParseWarning{
+			{ID: "02", Reason: "unknown primitive"},
+			{ID: "27", Reason: "unknown template"},
+			{ID: "70", Reason: "unknown primitive"},
+		}
+		if len(p.Warnings) != len(expected) {
+			t.Fatalf("expected %v warnings but got %v", expected, p.Warnings)
+		}
+		for i, w := range expected {
+			if p.Warnings[i] != w {
+				t.Errorf("expected warning %v but got %v", w, p.Warnings[i])
+			}
+		}
+
+		values := builder["27"].Unwrap()
+		if values["00"].Value != "com.example" || values["01"].Value != "1234" {
+			t.Errorf("unexpected template values: %v", values)
+		}
+
+		brCode, err := builder.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if brCode != code {
+			t.Errorf("expected %s but got %s", code, brCode)
+		}
+	})
+
+	t.Run("lenient parser should keep unknown template values", func(t *testing.T) {
+		code := Builder{}.addCRC16(
+			"000201" +
+				"26660014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400000404TEST" +
+				"5204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***",
+		)
+		p := NewParser()
+		if _, err := p.Parse(code); !errors.Is(err, ErrFieldMetadataNotFound) {
+			t.Errorf("expected ErrFieldMetadataNotFound but got: %v", err)
+		}
+
+		p.Lenient = true
+		builder, err := p.Parse(code)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(p.Warnings) != 1 || p.Warnings[0].ID != "26-04" {
+			t.Errorf("expected a warning for 26-04 but got %v", p.Warnings)
+		}
+		brCode, err := builder.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if brCode != code {
+			t.Errorf("expected %s but got %s", code, brCode)
+		}
+	})
+
+	t.Run("template ids with invalid values should be kept as primitives", func(t *testing.T) {
+		code := Builder{}.addCRC16(
+			"000201" +
+				"26580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-426655440000" +
+				"5204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***" +
+				"6405hello",
+		)
+		p := NewParser()
+		p.Lenient = true
+		builder, err := p.Parse(code)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := builder["64"].(*Primitive); !ok {
+			t.Errorf("expected field 64 to be kept as a primitive but got %T", builder["64"])
+		}
+	})
+}
+
+func TestUnreservedTemplates(t *testing.T) {
+	qr := NewStatic(
+		"maria@email.com", "Maria", "OURO PRETO", "231dsad",
+		WithUnreservedTemplate("80", UnreservedTemplate{GUI: "com.example", Values: map[string]string{"01": "store-42"}}),
+		WithUnreservedTemplate("99", UnreservedTemplate{GUI: "br.com.loyalty", Values: map[string]string{"01": "123", "02": "gold"}}),
+	)
+	brCode, err := qr.BRCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewParser()
+	static, err := p.ParseStatic(brCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(static.UnreservedTemplates) != len(qr.UnreservedTemplates) {
+		t.Fatalf("expected %v but got %v", qr.UnreservedTemplates, static.UnreservedTemplates)
+	}
+	for id, expected := range qr.UnreservedTemplates {
+		got := static.UnreservedTemplates[id]
+		if got.GUI != expected.GUI {
+			t.Errorf("expected gui %s for template %s but got %s", expected.GUI, id, got.GUI)
+		}
+		for vid, value := range expected.Values {
+			if got.Values[vid] != value {
+				t.Errorf("expected value %s for %s-%s but got %s", value, id, vid, got.Values[vid])
+			}
+		}
+	}
+
+	if _, err := NewStatic("maria@email.com", "Maria", "OURO PRETO", "231dsad", WithUnreservedTemplate("70", UnreservedTemplate{GUI: "com.example"})).BRCode(); !errors.Is(err, ErrInvalidUnreservedTemplateID) {
+		t.Errorf("expected ErrInvalidUnreservedTemplateID but got: %v", err)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	cases := []struct {
+		code     string
+		expected ParseError
+	}{
+		{
+			code: "00020126580014br.gov.bcb.pix0136123e4567",
+			expected: ParseError{
+				Offset: 10, Path: "26", Expected: 58, Remaining: 30,
+				Snippet: "00020126580014br.gov", Err: ErrUnexpectedEnd,
+			},
+		},
+		{
+			code: "00020152041",
+			expected: ParseError{
+				Offset: 10, Path: "52", Expected: 4, Remaining: 1,
+				Snippet: "00020152041", Err: ErrUnexpectedEnd,
+			},
+		},
+		{
+			code: "00020126200014br.gov.bcb.pix0136123e4567-e12b-42d1-a456-426655440000",
+			expected: ParseError{
+				Offset: 30, Path: "26-01", Expected: 2, Remaining: 0,
+				Snippet: ".bcb.pix0136123e4567", Err: ErrTemplateOverflow,
+			},
+		},
+		{
+			code: "0002010",
+			expected: ParseError{
+				Offset: 6, Path: "", Expected: 2, Remaining: 1,
+				Snippet: "0002010", Err: ErrUnexpectedEnd,
+			},
+		},
+		{
+			code: "00020152AA",
+			expected: ParseError{
+				Offset: 8, Path: "52", Expected: 2, Remaining: 2,
+				Snippet: "00020152AA", Err: ErrInvalidLength,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		p := NewParser()
+		_, err := p.Parse(c.code)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("expected ParseError for %s but got: %v", c.code, err)
+			continue
+		}
+		if *parseErr != c.expected {
+			t.Errorf("expected %+v but got %+v", c.expected, *parseErr)
+		}
+		if !errors.Is(err, c.expected.Err) {
+			t.Errorf("expected error to match %v", c.expected.Err)
+		}
+	}
+}
+
+func TestParseTemplateBounds(t *testing.T) {
+	t.Run("value crossing the template end should fail", func(t *testing.T) {
+		// Template 26 declares 40 bytes but the chave declares 36 after the GUI
+		code := Builder{}.addCRC16("00020126400014br.gov.bcb.pix0136123e4567-e12b-42d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA")
+		p := NewParser()
+		_, err := p.Parse(code)
+		if !errors.Is(err, ErrTemplateOverflow) {
+			t.Fatalf("expected ErrTemplateOverflow but got: %v", err)
+		}
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Path != "26-01" {
+			t.Errorf("expected error for 26-01 but got: %v", err)
+		}
+	})
+
+	t.Run("negative and signed lengths should fail", func(t *testing.T) {
+		for _, code := range []string{"00-1", "00+1", "26-10000", "2605 0001"} {
+			p := NewParser()
+			if _, err := p.Parse(code); err == nil {
+				t.Errorf("expected error for %s but got nil", code)
+			}
+		}
+	})
+}
+
+// Synthetic codes laid out like the ones bank apps emit: reusable and single
+// use, keys of every type, dynamic payload URLs, additional info, amounts and
+// postal codes. They aren't real bank-issued codes, anonymized real codes
+// should be added here as they become available.
+var bankCodes = []string{
+	"00020101021126360014br.gov.bcb.pix0114+551199999999952040000530398654041.005802BR5913FULANO DE TAL6009SAO PAULO62140510PEDIDO123463044A66",
+	"00020126580014br.gov.bcb.pix0136a629532e-7693-4846-852d-1bbff817b5a85204000053039865802BR5920MARIA DA SILVA SOUZA6008BRASILIA62070503***630465CA",
+	"00020101021226840014br.gov.bcb.pix2562pix.example.com.br/qr/v2/cobv/9d36b84fc70b478fb95c12729b90ca255204000053039865802BR5917LOJA EXEMPLO LTDA6014RIO DE JANEIRO62070503***630476B2",
+	"00020101021126510014br.gov.bcb.pix0114112223330001810211Pedido 45215204581253039865406250.505802BR5921PADARIA PAO QUENTINHO6014BELO HORIZONTE61083014007162290525ABCDEFGHIJ1234567890ABCDE6304AE4D",
+	"00020101021126330014br.gov.bcb.pix01115299822472552040000530398654040.295802BR5907CLIENTE6006RECIFE62070503***6304242F",
+	"00020101021226450014br.gov.bcb.pix0123joao.silva@email.com.br52040000530398654139999999999.995802BR5904JOAO6008CURITIBA62070503TX9630417CA",
+}
+
+func TestParseBankCodes(t *testing.T) {
+	amounts := []Amount{100, 0, 0, 25050, 29, 999999999999}
+	for i, code := range bankCodes {
+		p := NewParser()
+		builder, err := p.Parse(code)
+		if err != nil {
+			t.Errorf("unexpected error parsing %s: %v", code, err)
+			continue
+		}
+		brCode, err := builder.Build()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if brCode != code {
+			t.Errorf("expected %s but got %s", code, brCode)
+		}
+
+		amount, err := builder.GetTransactionAmount()
+		if err != nil {
+			t.Error(err)
+		}
+		if amount != amounts[i] {
+			t.Errorf("expected amount %v but got %v", amounts[i], amount)
+		}
+	}
+}
+
+func TestParseCanonicalForm(t *testing.T) {
+	cases := []struct {
+		code     string
+		expected error
+	}{
+		{code: "52040000000201", expected: ErrFieldOrder},
+		{code: "000201000201", expected: ErrDuplicateField},
+		{code: "00020163040000000201", expected: ErrFieldAfterCRC},
+		{code: "0002016100", expected: ErrEmptyValue},
+		{code: "0002016200", expected: ErrEmptyValue},
+		{code: "00020126260104abcd0014br.gov.bcb.pix", expected: ErrFieldOrder},
+	}
+	for _, c := range cases {
+		p := NewParser()
+		if _, err := p.Parse(c.code); !errors.Is(err, c.expected) {
+			t.Errorf("expected %v for %s but got: %v", c.expected, c.code, err)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(exampleCode)
+	for _, code := range bankCodes {
+		f.Add(code)
+	}
+	f.Add("00020126400014br.gov.bcb.pix0136123e4567")
+	f.Add("00020126-10014br.gov.bcb.pix")
+	f.Add("8005hello6304ABCD")
+
+	f.Fuzz(func(t *testing.T, code string) {
+		_, err := Parse(code)
+		verr := ValidateCode([]byte(code))
+		if (err == nil) != (verr == nil) || (err != nil && err.Error() != verr.Error()) {
+			t.Fatalf("ValidateCode of %q returned %v, but Parse returned: %v", code, verr, err)
+		}
+
+		for _, lenient := range []bool{false, true} {
+			p := NewParser()
+			p.Lenient = lenient
+			builder, err := p.Parse(code)
+			if err != nil {
+				continue
+			}
+			brCode, err := builder.Build()
+			if err != nil {
+				t.Fatalf("accepted code %q failed to build: %v", code, err)
+			}
+			if brCode != code {
+				t.Fatalf("accepted code %q was built as %q", code, brCode)
+			}
+		}
+	})
+}
+
+func FuzzStaticRoundTrip(f *testing.F) {
+	keys := []string{
+		"52998224725",
+		"11222333000181",
+		"maria@email.com",
+		"+5511999999999",
+		"123e4567-e12b-42d1-a456-426655440000",
+	}
+	f.Add(uint8(0), "Fulano de Tal", "BRASILIA", "***", 0, "", "0000", false)
+	f.Add(uint8(1), "José", "São Paulo", "abc123", 29, "01310100", "5812", true)
+	f.Add(uint8(2), "Maria", "OURO PRETO", "231dsad", 1000, "33400000", "0000", false)
+	f.Add(uint8(3), "LOJA", "RECIFE", "", 999999999999, "", "9999", true)
+
+	f.Fuzz(func(t *testing.T, key uint8, name, city, txId string, amount int, postalCode, mcc string, singleUse bool) {
+		fns := []StaticOptFn{
+			WithTransactionAmount(Amount(amount)),
+			WithPostalCode(postalCode),
+			WithMerchantCategoryCode(mcc),
+		}
+		if singleUse {
+			fns = append(fns, WithSingleUse())
+		}
+		qr := NewStatic(keys[int(key)%len(keys)], name, city, txId, fns...)
+		brCode, err := qr.BRCode()
+		if err != nil {
+			return
+		}
+
+		p := NewParser()
+		static, err := p.ParseStatic(brCode)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", brCode, err)
+		}
+		got := []any{static.Chave, static.MerchantName, static.MerchantCity, static.TransactionId, static.TransactionAmount,
+			static.PostalCode, static.MerchantCategoryCode, static.PointOfInitiationMethod, static.CountryCode, static.TransactionCurrency}
+		expected := []any{qr.Chave, qr.MerchantName, qr.MerchantCity, qr.TransactionId, qr.TransactionAmount,
+			qr.PostalCode, qr.MerchantCategoryCode, qr.PointOfInitiationMethod, qr.CountryCode, qr.TransactionCurrency}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Fatalf("expected %v but got %v for %q", expected, got, brCode)
+			}
+		}
+	})
+}
+
+func TestCRCOptions(t *testing.T) {
+	invalid := exampleCode[:len(exampleCode)-4] + "1D4D"
+	lowercase := exampleCode[:len(exampleCode)-4] + "1d3d"
+	missing := exampleCode[:len(exampleCode)-8]
+
+	t.Run("skip crc should read codes with invalid or missing crc", func(t *testing.T) {
+		for _, code := range []string{invalid, missing, lowercase} {
+			p := NewParser()
+			p.SkipCRC = true
+			static, err := p.ParseStatic(code)
+			if err != nil {
+				t.Errorf("unexpected error for %s: %v", code, err)
+				continue
+			}
+			if static.MerchantName != "Fulano de Tal" {
+				t.Errorf("expected merchant name to be read but got %s", static.MerchantName)
+			}
+
+			if _, err := p.ParseDocument(code); err != nil {
+				t.Errorf("unexpected error parsing document %s: %v", code, err)
+			}
+		}
+	})
+
+	t.Run("skip crc should still validate fields", func(t *testing.T) {
+		p := NewParser()
+		p.SkipCRC = true
+		if _, err := p.Parse("000201520300063041D3D"); !errors.Is(err, ErrFieldBelowMin) {
+			t.Errorf("expected ErrFieldBelowMin but got: %v", err)
+		}
+	})
+
+	t.Run("lowercase crc should only be accepted with option", func(t *testing.T) {
+		p := NewParser()
+		if _, err := p.Parse(lowercase); !errors.Is(err, ErrInvalidCRC) {
+			t.Errorf("expected ErrInvalidCRC but got: %v", err)
+		}
+		if _, err := p.ParseDocument(lowercase); !errors.Is(err, ErrInvalidCRC) {
+			t.Errorf("expected ErrInvalidCRC but got: %v", err)
+		}
+
+		p.AcceptLowercaseCRC = true
+		if _, err := p.Parse(lowercase); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := p.ParseDocument(lowercase); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := p.Parse(invalid); !errors.Is(err, ErrInvalidCRC) {
+			t.Errorf("expected ErrInvalidCRC but got: %v", err)
+		}
+	})
+
+	t.Run("repair crc should recompute field 63", func(t *testing.T) {
+		unknown := Builder{}.addCRC16(exampleCode[:len(exampleCode)-8] + "7003abc")
+		cases := []struct {
+			code     string
+			expected string
+		}{
+			{code: invalid, expected: exampleCode},
+			{code: lowercase, expected: exampleCode},
+			{code: missing, expected: exampleCode},
+			{code: exampleCode, expected: exampleCode},
+			{code: unknown[:len(unknown)-4] + "0000", expected: unknown},
+			{code: missing + "6304", expected: exampleCode},
+			{code: missing + "63041D", expected: exampleCode},
+			{code: missing + "630", expected: exampleCode},
+			{code: "000201" + "6304", expected: Builder{}.addCRC16("000201")},
+		}
+		for _, c := range cases {
+			repaired, err := RepairCRC(c.code)
+			if err != nil {
+				t.Errorf("unexpected error for %s: %v", c.code, err)
+				continue
+			}
+			if repaired != c.expected {
+				t.Errorf("expected %s but got %s", c.expected, repaired)
+			}
+		}
+	})
+
+	t.Run("repair crc should fail for malformed codes", func(t *testing.T) {
+		cases := []struct {
+			code     string
+			expected error
+		}{
+			{code: "", expected: ErrEmptyCode},
+			{code: "00020", expected: ErrUnexpectedEnd},
+			{code: "00020163041D3D52040000", expected: ErrFieldAfterCRC},
+		}
+		for _, c := range cases {
+			if _, err := RepairCRC(c.code); !errors.Is(err, c.expected) {
+				t.Errorf("expected %v for %s but got: %v", c.expected, c.code, err)
+			}
+		}
+	})
+}
+
+func TestValidateCode(t *testing.T) {
+	t.Run("should accept the codes accepted by Parse", func(t *testing.T) {
+		for _, code := range append([]string{exampleCode}, bankCodes...) {
+			if err := ValidateCode([]byte(code)); err != nil {
+				t.Errorf("unexpected error for %s: %v", code, err)
+			}
+			if _, err := Parse(code); err != nil {
+				t.Errorf("unexpected error parsing %s: %v", code, err)
+			}
+		}
+	})
+
+	t.Run("should return the same errors as Parse", func(t *testing.T) {
+		cases := []string{
+			"",
+			"0002",
+			"00020",
+			"0002AA",
+			"000200",
+			"52040000000201",
+			"000201000201",
+			"00020163040000000201",
+			"0002016200",
+			"00020126260104abcd0014br.gov.bcb.pix",
+			"000201260900050000063041234",
+			"00020162090599123456304ABCD",
+			"0002017003abc",
+			"0002015901\xff",
+			exampleCode[:len(exampleCode)-8],
+			exampleCode[:len(exampleCode)-4] + "1d3d",
+			exampleCode[:len(exampleCode)-4] + "0000",
+			exampleCode + "0002",
+			amountCode("10"),
+		}
+		for _, code := range cases {
+			err := ValidateCode([]byte(code))
+			if err == nil {
+				t.Errorf("expected error for %q", code)
+				continue
+			}
+			_, expected := NewParser().Parse(code)
+			if err.Error() != expected.Error() {
+				t.Errorf("expected %v for %q but got: %v", expected, code, err)
+			}
+		}
+	})
+
+	t.Run("should not allocate for valid codes", func(t *testing.T) {
+		code := []byte(exampleCode)
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := ValidateCode(code); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("expected no allocations but got %v", allocs)
+		}
+	})
+
+	t.Run("should be safe for concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, code := range bankCodes {
+					if err := ValidateCode([]byte(code)); err != nil {
+						t.Error(err)
+					}
+					builder, err := Parse(code)
+					if err != nil {
+						t.Error(err)
+						continue
+					}
+					if brCode, err := builder.Build(); err != nil || brCode != code {
+						t.Errorf("expected %s but got %s (%v)", code, brCode, err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func BenchmarkValidateCode(b *testing.B) {
+	code := []byte(exampleCode)
+	b.SetBytes(int64(len(code)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := ValidateCode(code); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "codes/s")
+}
+
+func BenchmarkValidateCodeParallel(b *testing.B) {
+	code := []byte(exampleCode)
+	b.SetBytes(int64(len(code)))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := ValidateCode(code); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(exampleCode); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Returns exampleCode with the amount added, using a valid CRC
+func amountCode(amount string) string {
+	data := exampleCode[:len(exampleCode)-8]
+	data = strings.Replace(data, "5303986", fmt.Sprintf("530398654%02d%s", len(amount), amount), 1)
+	return Builder{}.addCRC16(data)
+}
+
+func TestParseAmount(t *testing.T) {
+	invalid := []string{"10", "10.5", "10.123", "1e3", "-5", "NaN", "1,00"}
+
+	t.Run("should reject amounts outside the strict grammar", func(t *testing.T) {
+		for _, amount := range invalid {
+			code := amountCode(amount)
+			if _, err := NewParser().Parse(code); !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("expected ErrInvalidAmount for %s but got: %v", amount, err)
+			}
+			if err := ValidateCode([]byte(code)); !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("expected ErrInvalidAmount validating %s but got: %v", amount, err)
+			}
+		}
+	})
+
+	t.Run("should normalize lenient amounts when requested", func(t *testing.T) {
+		cases := []struct {
+			amount   string
+			value    string
+			expected Amount
+		}{
+			{amount: "10", value: "10.00", expected: 1000},
+			{amount: "10.5", value: "10.50", expected: 1050},
+			{amount: "0.29", value: "0.29", expected: 29},
+		}
+		for _, c := range cases {
+			p := NewParser()
+			p.NormalizeAmount = true
+			builder, err := p.Parse(amountCode(c.amount))
+			if err != nil {
+				t.Errorf("unexpected error for %s: %v", c.amount, err)
+				continue
+			}
+			if value, _ := builder.GetPrimitiveField("54"); value != c.value {
+				t.Errorf("expected %s to be normalized to %s but got %s", c.amount, c.value, value)
+			}
+			static, err := p.ParseStatic(amountCode(c.amount))
+			if err != nil {
+				t.Errorf("unexpected error for %s: %v", c.amount, err)
+				continue
+			}
+			if static.TransactionAmount != c.expected {
+				t.Errorf("expected %d but got %d", c.expected, static.TransactionAmount)
+			}
+			if _, err := builder.Build(); err != nil {
+				t.Errorf("expected normalized code to build but got: %v", err)
+			}
+		}
+	})
+
+	t.Run("normalizing should still reject invalid amounts and crcs", func(t *testing.T) {
+		p := NewParser()
+		p.NormalizeAmount = true
+		for _, amount := range []string{"10.123", "1e3", "-5", "NaN"} {
+			if _, err := p.Parse(amountCode(amount)); !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("expected ErrInvalidAmount for %s but got: %v", amount, err)
+			}
+		}
+
+		code := amountCode("10")
+		if _, err := p.Parse(code[:len(code)-4] + "0000"); !errors.Is(err, ErrInvalidCRC) {
+			t.Errorf("expected ErrInvalidCRC but got: %v", err)
+		}
+	})
+}
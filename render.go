@@ -0,0 +1,491 @@
+package qrpix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var (
+	ErrInvalidQuietZone = errors.New("quiet zone can't be negative")
+)
+
+// Error correction level of the QR code. Higher levels survive more damage,
+// at the cost of denser codes.
+type RecoveryLevel int
+
+const (
+	// Recovers 7% of the data
+	RecoveryLow RecoveryLevel = iota
+	// Recovers 15% of the data, the default
+	RecoveryMedium
+	// Recovers 25% of the data
+	RecoveryHigh
+	// Recovers 30% of the data
+	RecoveryHighest
+)
+
+func (l RecoveryLevel) qrcodeLevel() qrcode.RecoveryLevel {
+	switch l {
+	case RecoveryLow:
+		return qrcode.Low
+	case RecoveryHigh:
+		return qrcode.High
+	case RecoveryHighest:
+		return qrcode.Highest
+	default:
+		return qrcode.Medium
+	}
+}
+
+// Number of blank modules around the code required by the QR specification
+const defaultQuietZone = 4
+
+// Settings used to render QR code images
+type RenderOptions struct {
+	// Width and height of the image in pixels. Negative values set the
+	// pixels per module instead. Images too small to fit every module are
+	// enlarged.
+	Size          int
+	RecoveryLevel RecoveryLevel
+	// Width of the blank margin around the code, in modules
+	QuietZone  int
+	Foreground color.Color
+	Background color.Color
+	// Renders without the quiet zone, regardless of QuietZone
+	DisableBorder bool
+	// Terminal output only. Draws the light modules instead of the dark
+	// ones, for terminals with light text on a dark background.
+	Invert bool
+	// Terminal output only. Uses ASCII characters instead of Unicode half
+	// blocks, for terminals without Unicode support.
+	ASCII bool
+}
+
+type RenderOptFn func(*RenderOptions)
+
+// Returns the settings used when no option is given, a starting point for
+// presets passed with WithRenderOptions
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{
+		Size:          imageSize,
+		RecoveryLevel: RecoveryMedium,
+		QuietZone:     defaultQuietZone,
+		Foreground:    color.Black,
+		Background:    color.White,
+	}
+}
+
+func newRenderOptions(fns []RenderOptFn) RenderOptions {
+	o := DefaultRenderOptions()
+	for _, fn := range fns {
+		fn(&o)
+	}
+	return o
+}
+
+// Replaces every setting with a preset, such as one built from
+// DefaultRenderOptions. Options given after it are applied on top.
+func WithRenderOptions(preset RenderOptions) RenderOptFn {
+	return func(o *RenderOptions) {
+		*o = preset
+	}
+}
+
+// Sets the image size in pixels, negative values set the pixels per module
+func WithImageSize(size int) RenderOptFn {
+	return func(o *RenderOptions) {
+		o.Size = size
+	}
+}
+
+func WithRecoveryLevel(level RecoveryLevel) RenderOptFn {
+	return func(o *RenderOptions) {
+		o.RecoveryLevel = level
+	}
+}
+
+// Sets the width of the blank margin around the code, in modules
+func WithQuietZone(modules int) RenderOptFn {
+	return func(o *RenderOptions) {
+		o.QuietZone = modules
+	}
+}
+
+func WithColors(foreground, background color.Color) RenderOptFn {
+	return func(o *RenderOptions) {
+		o.Foreground = foreground
+		o.Background = background
+	}
+}
+
+// Renders the code without the quiet zone
+func WithoutBorder() RenderOptFn {
+	return func(o *RenderOptions) {
+		o.DisableBorder = true
+	}
+}
+
+// Draws light modules in terminal output, for dark terminals
+func WithInvert() RenderOptFn {
+	return func(o *RenderOptions) {
+		o.Invert = true
+	}
+}
+
+// Renders terminal output with ASCII characters
+func WithASCII() RenderOptFn {
+	return func(o *RenderOptions) {
+		o.ASCII = true
+	}
+}
+
+// Codes that can be rendered, built with BRCode
+type brCoder interface {
+	BRCode() (string, error)
+}
+
+// Builds the code and saves it as a PNG image in the specified path
+func saveCode(c brCoder, path string, opts []RenderOptFn) error {
+	brCode, err := c.BRCode()
+	if err != nil {
+		return err
+	}
+
+	return newRenderOptions(opts).saveFile(brCode, path)
+}
+
+// Builds the code and encodes it as a PNG image
+func encodeCode(c brCoder, opts []RenderOptFn) ([]byte, error) {
+	brCode, err := c.BRCode()
+	if err != nil {
+		return nil, err
+	}
+
+	return newRenderOptions(opts).png(brCode)
+}
+
+// Builds the code and writes it to w as a SVG image
+func encodeCodeSVG(c brCoder, w io.Writer, opts []RenderOptFn) error {
+	brCode, err := c.BRCode()
+	if err != nil {
+		return err
+	}
+
+	return newRenderOptions(opts).writeSVG(brCode, w)
+}
+
+// Builds the code and writes it to w as text for terminals
+func writeCodeTerminal(c brCoder, w io.Writer, opts []RenderOptFn) error {
+	brCode, err := c.BRCode()
+	if err != nil {
+		return err
+	}
+
+	return newRenderOptions(opts).writeTerminal(brCode, w)
+}
+
+// Builds the code and serves it as an image, negotiating the format
+func serveCode(c brCoder, w http.ResponseWriter, r *http.Request, opts []RenderOptFn) error {
+	brCode, err := c.BRCode()
+	if err != nil {
+		return err
+	}
+
+	return newRenderOptions(opts).serve(w, r, brCode)
+}
+
+// Returns the modules of the code, quiet zone included
+func (o RenderOptions) bitmap(content string) ([][]bool, error) {
+	if o.QuietZone < 0 && !o.DisableBorder {
+		return nil, ErrInvalidQuietZone
+	}
+	q, err := qrcode.New(content, o.RecoveryLevel.qrcodeLevel())
+	if err != nil {
+		return nil, err
+	}
+	// The quiet zone is added here, since its width is fixed by the encoder
+	q.DisableBorder = true
+	symbol := q.Bitmap()
+
+	quiet := o.QuietZone
+	if o.DisableBorder {
+		quiet = 0
+	}
+	n := len(symbol) + 2*quiet
+	bitmap := make([][]bool, n)
+	for y := range bitmap {
+		bitmap[y] = make([]bool, n)
+		if y >= quiet && y < n-quiet {
+			copy(bitmap[y][quiet:], symbol[y-quiet])
+		}
+	}
+	return bitmap, nil
+}
+
+// Returns the image size in pixels for a code with the number of modules
+func (o RenderOptions) pixels(modules int) int {
+	size := o.Size
+	if size < 0 {
+		size = -size * modules
+	}
+	if size < modules {
+		size = modules
+	}
+	return size
+}
+
+// Returns the foreground and background colors, using black and white when unset
+func (o RenderOptions) colors() (color.Color, color.Color) {
+	fg, bg := o.Foreground, o.Background
+	if fg == nil {
+		fg = color.Black
+	}
+	if bg == nil {
+		bg = color.White
+	}
+	return fg, bg
+}
+
+// Renders the code as an image with the options applied
+func (o RenderOptions) image(content string) (image.Image, error) {
+	bitmap, err := o.bitmap(content)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := len(bitmap)
+	size := o.pixels(modules)
+	fg, bg := o.colors()
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{bg, fg})
+	// Palette lookup would pick the background when both colors are equal
+	const fgIndex = 1
+
+	// Map each pixel to its module, without floats so scaling is exact
+	for y := 0; y < size; y++ {
+		row := bitmap[y*modules/size]
+		for x := 0; x < size; x++ {
+			if row[x*modules/size] {
+				img.Pix[img.PixOffset(x, y)] = fgIndex
+			}
+		}
+	}
+	return img, nil
+}
+
+// Renders the code as a PNG image
+func (o RenderOptions) png(content string) ([]byte, error) {
+	img, err := o.image(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Renders the code as a PNG image and saves it in the specified path
+func (o RenderOptions) saveFile(content, path string) error {
+	png, err := o.png(content)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, png, 0644)
+}
+
+// Renders the code as a SVG image from the same modules as the PNG. Each
+// module is a unit of the view box, so the image scales without blurring.
+func (o RenderOptions) svg(content string) ([]byte, error) {
+	bitmap, err := o.bitmap(content)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := len(bitmap)
+	size := o.pixels(modules)
+	fg, bg := o.colors()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" %s/>`, modules, modules, svgFill(bg))
+	fmt.Fprintf(&b, `<path %s d="`, svgFill(fg))
+	// Consecutive dark modules of a row are drawn as a single rectangle
+	for y, row := range bitmap {
+		for x := 0; x < modules; {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < modules && row[x] {
+				x++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes(), nil
+}
+
+// Returns the fill attributes of the color, with the opacity when not opaque
+func svgFill(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	fill := fmt.Sprintf(`fill="#%02x%02x%02x"`, n.R, n.G, n.B)
+	if n.A != 0xff {
+		fill += fmt.Sprintf(` fill-opacity="%.3f"`, float64(n.A)/0xff)
+	}
+	return fill
+}
+
+// Renders the code as a SVG image and writes it to w
+func (o RenderOptions) writeSVG(content string, w io.Writer) error {
+	svg, err := o.svg(content)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(svg)
+	return err
+}
+
+// Serves the code as a SVG image when the request prefers it over PNG,
+// otherwise as a PNG image. Without a request, or without an Accept header,
+// the code is served as PNG.
+func (o RenderOptions) serve(w http.ResponseWriter, r *http.Request, content string) error {
+	contentType := "image/png"
+	render := o.png
+	if r != nil && prefersSVG(r.Header.Get("Accept")) {
+		contentType = "image/svg+xml"
+		render = o.svg
+	}
+
+	// Rendered before writing headers, so errors can still be reported
+	img, err := render(content)
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		w.Header().Add("Vary", "Accept")
+	}
+	w.Header().Add("Content-Type", contentType)
+	w.Write(img)
+	return nil
+}
+
+// Reports whether the Accept header ranks SVG above PNG. Ties go to PNG.
+func prefersSVG(accept string) bool {
+	if accept == "" {
+		return false
+	}
+	return acceptQuality(accept, "image/svg+xml") > acceptQuality(accept, "image/png")
+}
+
+// Returns the quality the Accept header gives to the media type, using the
+// most specific matching range.
+func acceptQuality(accept, mediaType string) float64 {
+	kind, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		var s int
+		switch t {
+		case mediaType:
+			s = 2
+		case kind + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s < specificity {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		quality, specificity = q, s
+	}
+	return quality
+}
+
+// Renders the code as text. Half blocks draw two rows of modules per line,
+// so modules are roughly square in most fonts. The ASCII fallback draws a
+// row per line with two characters per module. Size and colors are ignored.
+func (o RenderOptions) terminal(content string) ([]byte, error) {
+	bitmap, err := o.bitmap(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whether the module is drawn, rows past the end are part of the margin
+	drawn := func(x, y int) bool {
+		dark := y < len(bitmap) && bitmap[y][x]
+		return dark != o.Invert
+	}
+
+	var b bytes.Buffer
+	n := len(bitmap)
+	if o.ASCII {
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				if drawn(x, y) {
+					b.WriteString("##")
+				} else {
+					b.WriteString("  ")
+				}
+			}
+			b.WriteByte('\n')
+		}
+		return b.Bytes(), nil
+	}
+
+	for y := 0; y < n; y += 2 {
+		for x := 0; x < n; x++ {
+			top, bottom := drawn(x, y), drawn(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
+
+// Renders the code as text and writes it to w
+func (o RenderOptions) writeTerminal(content string, w io.Writer) error {
+	text, err := o.terminal(content)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(text)
+	return err
+}
@@ -0,0 +1,445 @@
+package qrpix
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func decodePNG(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+	return img
+}
+
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
+
+func TestRender(t *testing.T) {
+	static := NewStatic("123e4567-e12b-42d1-a456-426655440000", "Fulano de Tal", "BRASILIA", "***")
+	code, err := static.BRCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbol, err := newRenderOptions([]RenderOptFn{WithoutBorder()}).bitmap(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules := len(symbol)
+
+	t.Run("presets should be applied with later options on top", func(t *testing.T) {
+		sticker := DefaultRenderOptions()
+		sticker.Size = 512
+		sticker.RecoveryLevel = RecoveryHigh
+		sticker.Background = color.RGBA{R: 0xff, A: 0xff}
+
+		o := newRenderOptions([]RenderOptFn{WithImageSize(128), WithRenderOptions(sticker), WithQuietZone(2)})
+		if o.Size != 512 || o.RecoveryLevel != RecoveryHigh || o.QuietZone != 2 {
+			t.Errorf("expected preset with quiet zone 2 but got %+v", o)
+		}
+
+		data, err := static.Encode(WithRenderOptions(sticker))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := decodePNG(t, data)
+		if img.Bounds().Dx() != 512 {
+			t.Errorf("expected 512px image but got %v", img.Bounds())
+		}
+		if !sameColor(img.At(0, 0), sticker.Background) {
+			t.Errorf("expected preset background but got %v", img.At(0, 0))
+		}
+	})
+
+	t.Run("default options should render a 256px image with a quiet zone", func(t *testing.T) {
+		data, err := static.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := decodePNG(t, data)
+		if img.Bounds().Dx() != imageSize || img.Bounds().Dy() != imageSize {
+			t.Errorf("expected %dpx image but got %v", imageSize, img.Bounds())
+		}
+		if !sameColor(img.At(0, 0), color.White) {
+			t.Errorf("expected quiet zone to be white but got %v", img.At(0, 0))
+		}
+	})
+
+	t.Run("negative size should set pixels per module", func(t *testing.T) {
+		data, err := static.Encode(WithImageSize(-3), WithQuietZone(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := decodePNG(t, data)
+		expected := (modules + 4) * 3
+		if img.Bounds().Dx() != expected {
+			t.Fatalf("expected %dpx image but got %v", expected, img.Bounds())
+		}
+		// The finder pattern starts right after the quiet zone
+		if !sameColor(img.At(5, 5), color.White) || !sameColor(img.At(6, 6), color.Black) {
+			t.Errorf("expected code to start after 2 modules of quiet zone")
+		}
+	})
+
+	t.Run("small sizes should be enlarged to fit every module", func(t *testing.T) {
+		data, err := static.Encode(WithImageSize(10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := decodePNG(t, data)
+		if img.Bounds().Dx() != modules+2*defaultQuietZone {
+			t.Errorf("expected %dpx image but got %v", modules+2*defaultQuietZone, img.Bounds())
+		}
+	})
+
+	t.Run("disabled border should start with the finder pattern", func(t *testing.T) {
+		data, err := static.Encode(WithImageSize(-1), WithQuietZone(8), WithoutBorder())
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := decodePNG(t, data)
+		if img.Bounds().Dx() != modules {
+			t.Errorf("expected %dpx image but got %v", modules, img.Bounds())
+		}
+		if !sameColor(img.At(0, 0), color.Black) {
+			t.Errorf("expected first pixel to be part of the finder pattern")
+		}
+	})
+
+	t.Run("colors should be applied", func(t *testing.T) {
+		fg := color.RGBA{R: 0x32, G: 0xbc, B: 0xad, A: 0xff}
+		bg := color.RGBA{R: 0xfa, G: 0xfa, B: 0xf0, A: 0xff}
+		data, err := static.Encode(WithImageSize(-1), WithColors(fg, bg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := decodePNG(t, data)
+		q := defaultQuietZone
+		if !sameColor(img.At(0, 0), bg) || !sameColor(img.At(q, q), fg) {
+			t.Errorf("expected custom colors but got %v and %v", img.At(0, 0), img.At(q, q))
+		}
+	})
+
+	t.Run("higher recovery levels should render denser codes", func(t *testing.T) {
+		low, err := newRenderOptions([]RenderOptFn{WithRecoveryLevel(RecoveryLow)}).bitmap(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		highest, err := newRenderOptions([]RenderOptFn{WithRecoveryLevel(RecoveryHighest)}).bitmap(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(highest) <= len(low) {
+			t.Errorf("expected highest level to use more modules than low, got %d and %d", len(highest), len(low))
+		}
+	})
+
+	t.Run("negative quiet zone should return error", func(t *testing.T) {
+		if _, err := static.Encode(WithQuietZone(-1)); !errors.Is(err, ErrInvalidQuietZone) {
+			t.Errorf("expected ErrInvalidQuietZone but got: %v", err)
+		}
+	})
+
+	t.Run("save file and serve should apply options", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "qr.png")
+		if err := static.SaveFile(path, WithImageSize(512)); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img := decodePNG(t, data); img.Bounds().Dx() != 512 {
+			t.Errorf("expected saved image to be 512px but got %v", img.Bounds())
+		}
+
+		rec := httptest.NewRecorder()
+		if err := static.Serve(rec, WithImageSize(128)); err != nil {
+			t.Fatal(err)
+		}
+		if img := decodePNG(t, rec.Body.Bytes()); img.Bounds().Dx() != 128 {
+			t.Errorf("expected served image to be 128px but got %v", img.Bounds())
+		}
+	})
+
+	t.Run("dynamic codes should accept options", func(t *testing.T) {
+		dynamic := NewDynamic("pix.example.com/qr/v2/9d36b84f", "Fulano de Tal", "BRASILIA")
+		data, err := dynamic.Encode(WithImageSize(300), WithRecoveryLevel(RecoveryHigh))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img := decodePNG(t, data); img.Bounds().Dx() != 300 {
+			t.Errorf("expected 300px image but got %v", img.Bounds())
+		}
+	})
+}
+
+var svgRun = regexp.MustCompile(`M(\d+) (\d+)h(\d+)v1h-(\d+)z`)
+
+func TestRenderSVG(t *testing.T) {
+	static := NewStatic("123e4567-e12b-42d1-a456-426655440000", "Fulano de Tal", "BRASILIA", "***")
+	code, err := static.BRCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should draw the same modules as the png", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := static.EncodeSVG(&b, WithQuietZone(2)); err != nil {
+			t.Fatal(err)
+		}
+		var svg struct {
+			Width   string `xml:"width,attr"`
+			ViewBox string `xml:"viewBox,attr"`
+			Path    struct {
+				D string `xml:"d,attr"`
+			} `xml:"path"`
+		}
+		if err := xml.Unmarshal(b.Bytes(), &svg); err != nil {
+			t.Fatalf("expected valid xml but got: %v", err)
+		}
+
+		bitmap, err := newRenderOptions([]RenderOptFn{WithQuietZone(2)}).bitmap(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := len(bitmap)
+		if svg.Width != strconv.Itoa(imageSize) {
+			t.Errorf("expected width %d but got %s", imageSize, svg.Width)
+		}
+		if expected := fmt.Sprintf("0 0 %d %d", n, n); svg.ViewBox != expected {
+			t.Errorf("expected view box %s but got %s", expected, svg.ViewBox)
+		}
+
+		drawn := make([][]bool, n)
+		for i := range drawn {
+			drawn[i] = make([]bool, n)
+		}
+		for _, m := range svgRun.FindAllStringSubmatch(svg.Path.D, -1) {
+			x, _ := strconv.Atoi(m[1])
+			y, _ := strconv.Atoi(m[2])
+			w, _ := strconv.Atoi(m[3])
+			for i := x; i < x+w; i++ {
+				drawn[y][i] = true
+			}
+		}
+		for y := range bitmap {
+			for x := range bitmap[y] {
+				if bitmap[y][x] != drawn[y][x] {
+					t.Fatalf("module (%d, %d) differs from the png modules", x, y)
+				}
+			}
+		}
+	})
+
+	t.Run("should apply colors and opacity", func(t *testing.T) {
+		var b bytes.Buffer
+		fg := color.NRGBA{R: 0x32, G: 0xbc, B: 0xad, A: 0xff}
+		bg := color.NRGBA{A: 0}
+		if err := static.EncodeSVG(&b, WithColors(fg, bg)); err != nil {
+			t.Fatal(err)
+		}
+		svg := b.String()
+		if !strings.Contains(svg, `<path fill="#32bcad"`) {
+			t.Errorf("expected foreground color in %s", svg)
+		}
+		if !strings.Contains(svg, `fill="#000000" fill-opacity="0.000"/>`) {
+			t.Errorf("expected transparent background in %s", svg)
+		}
+	})
+
+	t.Run("dynamic codes should encode svg", func(t *testing.T) {
+		var b bytes.Buffer
+		dynamic := NewDynamic("pix.example.com/qr/v2/9d36b84f", "Fulano de Tal", "BRASILIA")
+		if err := dynamic.EncodeSVG(&b, WithImageSize(-4)); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(b.String(), "<svg") {
+			t.Errorf("expected svg but got %s", b.String())
+		}
+	})
+}
+
+func TestServeContentNegotiation(t *testing.T) {
+	static := NewStatic("123e4567-e12b-42d1-a456-426655440000", "Fulano de Tal", "BRASILIA", "***")
+	cases := []struct {
+		accept   string
+		expected string
+	}{
+		{accept: "", expected: "image/png"},
+		{accept: "image/png", expected: "image/png"},
+		{accept: "image/svg+xml", expected: "image/svg+xml"},
+		{accept: "image/*", expected: "image/png"},
+		{accept: "*/*", expected: "image/png"},
+		{accept: "text/html", expected: "image/png"},
+		{accept: "image/svg+xml;q=0.9, image/png", expected: "image/png"},
+		{accept: "image/png;q=0.5, image/svg+xml", expected: "image/svg+xml"},
+		{accept: "image/svg+xml, image/*;q=0.8", expected: "image/svg+xml"},
+		{accept: "text/html,image/svg+xml;q=0.9,*/*;q=0.8", expected: "image/svg+xml"},
+		{accept: "image/svg+xml;q=0", expected: "image/png"},
+	}
+	for _, c := range cases {
+		t.Run(c.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.accept != "" {
+				r.Header.Set("Accept", c.accept)
+			}
+			rec := httptest.NewRecorder()
+			if err := static.ServeRequest(rec, r); err != nil {
+				t.Fatal(err)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != c.expected {
+				t.Errorf("expected %s but got %s", c.expected, ct)
+			}
+			if vary := rec.Header().Get("Vary"); vary != "Accept" {
+				t.Errorf("expected Vary: Accept but got %s", vary)
+			}
+			if c.expected == "image/png" {
+				decodePNG(t, rec.Body.Bytes())
+			} else if !strings.HasPrefix(rec.Body.String(), "<svg") {
+				t.Errorf("expected svg body but got %s", rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("Serve should always serve PNG", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		if err := static.Serve(rec); err != nil {
+			t.Fatal(err)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+			t.Errorf("expected image/png but got %s", ct)
+		}
+		if vary := rec.Header().Get("Vary"); vary != "" {
+			t.Errorf("expected no Vary header but got %s", vary)
+		}
+		decodePNG(t, rec.Body.Bytes())
+	})
+}
+
+// Reads the modules back from terminal output
+func decodeTerminal(t *testing.T, text string, ascii, invert bool) [][]bool {
+	t.Helper()
+	var bitmap [][]bool
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if ascii {
+			row := []bool{}
+			for i := 0; i+1 < len(line); i += 2 {
+				row = append(row, (line[i:i+2] == "##") != invert)
+			}
+			bitmap = append(bitmap, row)
+			continue
+		}
+		top, bottom := []bool{}, []bool{}
+		for _, r := range line {
+			upper := r == '█' || r == '▀'
+			lower := r == '█' || r == '▄'
+			if r != ' ' && !upper && !lower {
+				t.Fatalf("unexpected character %q", r)
+			}
+			top = append(top, upper != invert)
+			bottom = append(bottom, lower != invert)
+		}
+		bitmap = append(bitmap, top, bottom)
+	}
+	return bitmap
+}
+
+func TestRenderTerminal(t *testing.T) {
+	static := NewStatic("123e4567-e12b-42d1-a456-426655440000", "Fulano de Tal", "BRASILIA", "***")
+	code, err := static.BRCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		opts []RenderOptFn
+	}{
+		{name: "half blocks", opts: nil},
+		{name: "half blocks inverted", opts: []RenderOptFn{WithInvert()}},
+		{name: "half blocks without border", opts: []RenderOptFn{WithoutBorder()}},
+		{name: "ascii", opts: []RenderOptFn{WithASCII()}},
+		{name: "ascii inverted", opts: []RenderOptFn{WithASCII(), WithInvert(), WithQuietZone(1)}},
+	}
+	for _, c := range cases {
+		t.Run(c.name+" should draw every module", func(t *testing.T) {
+			o := newRenderOptions(c.opts)
+			var b bytes.Buffer
+			if err := static.WriteTerminal(&b, c.opts...); err != nil {
+				t.Fatal(err)
+			}
+			expected, err := o.bitmap(code)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := decodeTerminal(t, b.String(), o.ASCII, o.Invert)
+			n := len(expected)
+			// Half blocks pad odd codes with a light row
+			if len(got) < n || len(got) > n+1 {
+				t.Fatalf("expected %d rows but got %d", n, len(got))
+			}
+			for y := range got {
+				if len(got[y]) != n {
+					t.Fatalf("expected %d columns in row %d but got %d", n, y, len(got[y]))
+				}
+				for x := range got[y] {
+					dark := y < n && expected[y][x]
+					if got[y][x] != dark {
+						t.Fatalf("module (%d, %d) differs from the code modules", x, y)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("quiet zone should be light", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := static.WriteTerminal(&b); err != nil {
+			t.Fatal(err)
+		}
+		first := strings.Split(b.String(), "\n")[0]
+		if strings.TrimSpace(first) != "" {
+			t.Errorf("expected first line to be blank but got %q", first)
+		}
+
+		b.Reset()
+		if err := static.WriteTerminal(&b, WithInvert()); err != nil {
+			t.Fatal(err)
+		}
+		first = strings.Split(b.String(), "\n")[0]
+		if strings.Trim(first, "█") != "" {
+			t.Errorf("expected first line to be filled when inverted but got %q", first)
+		}
+	})
+
+	t.Run("dynamic codes should write to terminal", func(t *testing.T) {
+		var b bytes.Buffer
+		dynamic := NewDynamic("pix.example.com/qr/v2/9d36b84f", "Fulano de Tal", "BRASILIA")
+		if err := dynamic.WriteTerminal(&b, WithASCII()); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(b.String(), "##") {
+			t.Errorf("expected ascii output but got %s", b.String())
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package qrpix
+
+import (
+	"strings"
+)
+
+// Change applied by the sanitizer to a field value
+type SanitizeAction string
+
+const (
+	SanitizeRemovedDiacritics   SanitizeAction = "removed diacritics"
+	SanitizeUppercased          SanitizeAction = "uppercased"
+	SanitizeCollapsedWhitespace SanitizeAction = "collapsed whitespace"
+	SanitizeTruncated           SanitizeAction = "truncated"
+)
+
+// Result of sanitizing a field value
+type Sanitized struct {
+	ID       string
+	Original string
+	Value    string
+	// Changes applied to the value, empty if it was kept as is
+	Actions []SanitizeAction
+}
+
+// Reports whether the sanitizer changed the value
+func (s Sanitized) Changed() bool {
+	return len(s.Actions) > 0
+}
+
+// Sanitizes a value for the given field id: diacritics are removed, the value
+// is uppercased, whitespace is collapsed and the value is truncated at a word
+// boundary to the field max size. Characters without a transliteration are
+// kept, so the charset policy still applies to the result.
+func SanitizeField(id, value string) (Sanitized, error) {
+	meta, err := GetFieldMetadata(id)
+	if err != nil {
+		return Sanitized{}, err
+	}
+
+	res := Sanitized{
+		ID:       id,
+		Original: value,
+	}
+
+	if v := removeDiacritics(value); v != value {
+		res.Actions = append(res.Actions, SanitizeRemovedDiacritics)
+		value = v
+	}
+	if v := strings.ToUpper(value); v != value {
+		res.Actions = append(res.Actions, SanitizeUppercased)
+		value = v
+	}
+	if v := strings.Join(strings.Fields(value), " "); v != value {
+		res.Actions = append(res.Actions, SanitizeCollapsedWhitespace)
+		value = v
+	}
+	if v := truncateWords(value, meta.MaxSize); v != value {
+		res.Actions = append(res.Actions, SanitizeTruncated)
+		value = v
+	}
+
+	res.Value = value
+	return res, nil
+}
+
+func removeDiacritics(value string) string {
+	b := strings.Builder{}
+	b.Grow(len(value))
+	for _, r := range value {
+		if t, ok := transliterations[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Truncates the value to at most limit bytes, cutting at the last space that
+// fits. Single words longer than limit are cut at the last rune that fits.
+func truncateWords(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	cut := value[:limit+1]
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		return strings.TrimRight(cut[:i], " ")
+	}
+	for i := limit; i > 0; i-- {
+		if isRuneStart(value[i]) {
+			return value[:i]
+		}
+	}
+	return ""
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xc0 != 0x80
+}
@@ -0,0 +1,73 @@
+package qrpix
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	t.Run("sanitize field should normalize and report changes", func(t *testing.T) {
+		cases := []struct {
+			id       string
+			value    string
+			expected string
+			actions  []SanitizeAction
+		}{
+			{id: "60", value: "BRASILIA", expected: "BRASILIA"},
+			{id: "60", value: "São José dos Campos", expected: "SAO JOSE DOS CAMPOS", actions: []SanitizeAction{SanitizeRemovedDiacritics, SanitizeUppercased}},
+			{id: "59", value: "  FULANO   DE TAL ", expected: "FULANO DE TAL", actions: []SanitizeAction{SanitizeCollapsedWhitespace}},
+			{id: "59", value: "Padaria e Confeitaria São João", expected: "PADARIA E CONFEITARIA SAO", actions: []SanitizeAction{SanitizeRemovedDiacritics, SanitizeUppercased, SanitizeTruncated}},
+			{id: "59", value: "ABCDEFGHIJKLMNOPQRSTUVWXYZ", expected: "ABCDEFGHIJKLMNOPQRSTUVWXY", actions: []SanitizeAction{SanitizeTruncated}},
+			{id: "60", value: "東京", expected: "東京"},
+		}
+		for _, c := range cases {
+			got, err := SanitizeField(c.id, c.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Value != c.expected {
+				t.Errorf("expected %q but got %q", c.expected, got.Value)
+			}
+			if got.Original != c.value || got.ID != c.id {
+				t.Errorf("expected original %q for %s but got %q for %s", c.value, c.id, got.Original, got.ID)
+			}
+			if !reflect.DeepEqual(got.Actions, c.actions) {
+				t.Errorf("expected actions %v for %q but got %v", c.actions, c.value, got.Actions)
+			}
+		}
+	})
+
+	t.Run("truncate should not split runes", func(t *testing.T) {
+		if got := truncateWords("AAAAé", 5); got != "AAAA" {
+			t.Errorf("expected AAAA but got %q", got)
+		}
+	})
+
+	t.Run("static with sanitization should encode sanitized values", func(t *testing.T) {
+		qr := NewStatic("maria@email.com", "Maria  da Silva", "São José dos Campos", "abc123", WithSanitization())
+
+		changes, err := qr.Sanitize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 2 || changes[0].ID != "59" || changes[1].ID != "60" {
+			t.Fatalf("expected changes for 59 and 60 but got %+v", changes)
+		}
+
+		brCode, err := qr.BRCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewParser()
+		static, err := p.ParseStatic(brCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if static.MerchantName != "MARIA DA SILVA" || static.MerchantCity != "SAO JOSE DOS CAMPOS" {
+			t.Errorf("expected sanitized values but got %q, %q", static.MerchantName, static.MerchantCity)
+		}
+		if qr.MerchantCity != "São José dos Campos" {
+			t.Errorf("expected original city to be kept but got %q", qr.MerchantCity)
+		}
+	})
+}
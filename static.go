@@ -1,9 +1,9 @@
 package qrpix
 
 import (
+	"io"
 	"net/http"
-
-	qrcode "github.com/skip2/go-qrcode"
+	"sort"
 )
 
 const (
@@ -11,6 +11,14 @@ const (
 
 	PIXGui                 = "br.gov.bcb.pix"
 	PayloadFormatIndicator = "01"
+
+	PointOfInitiationMethodReusable  = "11"
+	PointOfInitiationMethodSingleUse = "12"
+
+	// Defaults of the fields static and dynamic codes share
+	defaultMerchantCategoryCode = "0000"
+	defaultTransactionCurrency  = "986"
+	defaultCountryCode          = "BR"
 )
 
 type Static struct {
@@ -22,10 +30,16 @@ type Static struct {
 	MerchantCity         string `json:"merchantCity"`
 	PostalCode           string `json:"postalCode"`
 	TransactionId        string `json:"transactionId"`
+	// Either PointOfInitiationMethodReusable, PointOfInitiationMethodSingleUse or empty
+	PointOfInitiationMethod string `json:"pointOfInitiationMethod"`
 	// Transaction amount in cents
-	TransactionAmount int `json:"transactionAmount"`
+	TransactionAmount Amount `json:"transactionAmount"`
+	// Partner specific templates by id (80-99)
+	UnreservedTemplates map[string]UnreservedTemplate `json:"unreservedTemplates,omitempty"`
 
-	builder Builder
+	normalizeKey bool
+	sanitize     bool
+	charset      Charset
 }
 
 type StaticOptFn func(*Static)
@@ -33,13 +47,12 @@ type StaticOptFn func(*Static)
 func NewStatic(chave, merchantName, merchantCity, txId string, fns ...StaticOptFn) *Static {
 	qr := &Static{
 		Chave:                chave,
-		MerchantCategoryCode: "0000",
-		TransactionCurrency:  "986",
-		CountryCode:          "BR",
+		MerchantCategoryCode: defaultMerchantCategoryCode,
+		TransactionCurrency:  defaultTransactionCurrency,
+		CountryCode:          defaultCountryCode,
 		MerchantName:         merchantName,
 		MerchantCity:         merchantCity,
 		TransactionId:        txId,
-		builder:              Builder{},
 	}
 	for _, fn := range fns {
 		fn(qr)
@@ -47,12 +60,51 @@ func NewStatic(chave, merchantName, merchantCity, txId string, fns ...StaticOptF
 	return qr
 }
 
-func WithTransactionAmount(value int) StaticOptFn {
+func WithTransactionAmount(value Amount) StaticOptFn {
 	return func(s *Static) {
 		s.TransactionAmount = value
 	}
 }
 
+// Normalizes the chave with NormalizeKey before validating and encoding it
+func WithKeyNormalization() StaticOptFn {
+	return func(s *Static) {
+		s.normalizeKey = true
+	}
+}
+
+// Sets the charset policy applied to every value, defaults to CharsetUTF8
+func WithCharset(c Charset) StaticOptFn {
+	return func(s *Static) {
+		s.charset = c
+	}
+}
+
+// Sanitizes the merchant name and city with SanitizeField before encoding.
+// Use Static.Sanitize to report the changes.
+func WithSanitization() StaticOptFn {
+	return func(s *Static) {
+		s.sanitize = true
+	}
+}
+
+// Marks the code as single use, so it can't be paid more than once
+func WithSingleUse() StaticOptFn {
+	return func(s *Static) {
+		s.PointOfInitiationMethod = PointOfInitiationMethodSingleUse
+	}
+}
+
+// Adds an unreserved template (80-99) carrying partner specific data
+func WithUnreservedTemplate(id string, t UnreservedTemplate) StaticOptFn {
+	return func(s *Static) {
+		if s.UnreservedTemplates == nil {
+			s.UnreservedTemplates = map[string]UnreservedTemplate{}
+		}
+		s.UnreservedTemplates[id] = t
+	}
+}
+
 func WithPostalCode(postalCode string) StaticOptFn {
 	return func(s *Static) {
 		s.PostalCode = postalCode
@@ -71,60 +123,180 @@ func WithMerchantCategoryCode(code string) StaticOptFn {
 	}
 }
 
+// Builds the BRCode. Fields are encoded straight from the struct, without a
+// Builder, so it's safe for concurrent use as long as the fields aren't
+// modified meanwhile.
 func (s *Static) BRCode() (string, error) {
-	defer s.builder.Clear()
+	// Empty chaves are reported when building
+	if chave := s.chave(); chave != "" {
+		if err := ValidateKey(chave); err != nil {
+			return "", newFieldError("26-01", chave, ReasonInvalidKey)
+		}
+	}
 
-	s.builder.AddPayloadFormatIndicator(PayloadFormatIndicator)
-	s.builder.AddMerchantAccountInformation(PIXGui, s.Chave)
-	s.builder.AddMerchantCategoryCode(s.MerchantCategoryCode)
-	s.builder.AddTransactionCurrency(s.TransactionCurrency)
-	s.builder.AddTransactionAmount(s.TransactionAmount)
-	s.builder.AddCountryCode(s.CountryCode)
-	s.builder.AddMerchantName(s.MerchantName)
-	s.builder.AddMerchantCity(s.MerchantCity)
-	s.builder.AddPostalCode(s.PostalCode)
-	s.builder.AddAdditionalDataField(s.TransactionId)
+	tlvs, errs := s.fields()
+	if len(errs) > 0 {
+		return "", errs[0]
+	}
 
-	return s.builder.Build()
+	return encodeTLVs(tlvs, s.charset, true, true)
 }
 
-// Creates and saves a QRCode in the specified path. Image format is PNG.
-func (s Static) SaveFile(path string) error {
-	brCode, err := s.BRCode()
+// Validates every field, returning all problems found instead of stopping at
+// the first one. Options such as key normalization and sanitization are
+// applied before validating.
+func (s Static) Validate() []error {
+	builder := Builder{}
+	errs := s.fill(builder)
+	return append(errs, builder.validate(s.charset)...)
+}
+
+// Returns the chave, normalized if requested
+func (s Static) chave() string {
+	if s.normalizeKey {
+		return NormalizeKey(s.Chave)
+	}
+	return s.Chave
+}
+
+// Adds every field to the builder. Fields that can't be added are reported
+// and skipped.
+func (s Static) fill(b Builder) []error {
+	tlvs, errs := s.fields()
+	for _, tlv := range tlvs {
+		b.Add(tlv)
+	}
+	return errs
+}
+
+// Returns every field of the code sorted by id. Fields that can't be created
+// are reported and skipped.
+func (s Static) fields() ([]TLV, []error) {
+	var amount string
+	if s.TransactionAmount != 0 {
+		amount = s.TransactionAmount.String()
+	}
+	primitives, err := s.merchantPrimitives(
+		Primitive{ID: "00", Value: PayloadFormatIndicator},
+		Primitive{ID: "01", Value: s.PointOfInitiationMethod},
+		Primitive{ID: "54", Value: amount},
+	)
 	if err != nil {
-		return err
+		return nil, []error{err}
 	}
 
-	if err := qrcode.WriteFile(brCode, qrcode.Medium, imageSize, path); err != nil {
-		return err
+	var errs []error
+	templates := make([]Template, 2, 2+len(s.UnreservedTemplates))
+	templates[0] = newMerchantAccountInformation(PIXGui, "01", s.chave())
+	templates[1] = newAdditionalDataField(s.TransactionId)
+	// Sorted so errors are reported in a stable order
+	ids := make([]string, 0, len(s.UnreservedTemplates))
+	for id := range s.UnreservedTemplates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		t, err := newUnreservedTemplate(id, s.UnreservedTemplates[id])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		templates = append(templates, t)
 	}
 
-	return nil
+	return sortedTLVs(primitives, templates), errs
 }
 
-// TODO: Add options as params
-func (s Static) Encode() ([]byte, error) {
-	brCode, err := s.BRCode()
+// Returns the primitives static and dynamic codes share, merchant category
+// code, currency, country code, merchant name and city, and postal code,
+// followed by extra. Primitives share one array, so building doesn't
+// allocate them one by one.
+func (s Static) merchantPrimitives(extra ...Primitive) ([]Primitive, error) {
+	name, city := s.MerchantName, s.MerchantCity
+	if s.sanitize {
+		changes, err := s.sanitized()
+		if err != nil {
+			return nil, err
+		}
+		name, city = changes[0].Value, changes[1].Value
+	}
+
+	shared := [...]Primitive{
+		{ID: "52", Value: s.MerchantCategoryCode},
+		{ID: "53", Value: s.TransactionCurrency},
+		{ID: "58", Value: s.CountryCode},
+		{ID: "59", Value: name},
+		{ID: "60", Value: city},
+		{ID: "61", Value: s.PostalCode},
+	}
+	primitives := make([]Primitive, 0, len(shared)+len(extra))
+	primitives = append(primitives, shared[:]...)
+	return append(primitives, extra...), nil
+}
+
+// Reports the changes the sanitizer makes to the merchant name and city.
+// Only changed fields are returned.
+func (s Static) Sanitize() ([]Sanitized, error) {
+	all, err := s.sanitized()
 	if err != nil {
 		return nil, err
 	}
+	var changes []Sanitized
+	for _, c := range all {
+		if c.Changed() {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
 
-	png, err := qrcode.Encode(brCode, qrcode.Medium, imageSize)
+// Sanitizes the merchant name and city, in this order
+func (s Static) sanitized() ([]Sanitized, error) {
+	name, err := SanitizeField("59", s.MerchantName)
+	if err != nil {
+		return nil, err
+	}
+	city, err := SanitizeField("60", s.MerchantCity)
 	if err != nil {
 		return nil, err
 	}
+	return []Sanitized{name, city}, nil
+}
+
+// Reports whether the code can only be paid once
+func (s Static) IsSingleUse() bool {
+	return s.PointOfInitiationMethod == PointOfInitiationMethodSingleUse
+}
 
-	return png, nil
+// Creates and saves a QRCode in the specified path. Image format is PNG.
+func (s Static) SaveFile(path string, opts ...RenderOptFn) error {
+	return saveCode(&s, path, opts)
+}
+
+// Encodes the QRCode as a PNG image
+func (s Static) Encode(opts ...RenderOptFn) ([]byte, error) {
+	return encodeCode(&s, opts)
+}
+
+// Encodes the QRCode as a SVG image and writes it to w
+func (s Static) EncodeSVG(w io.Writer, opts ...RenderOptFn) error {
+	return encodeCodeSVG(&s, w, opts)
+}
+
+// Writes the QRCode as text for terminals, using Unicode half blocks or
+// ASCII with WithASCII. Use WithInvert for dark terminals.
+func (s Static) WriteTerminal(w io.Writer, opts ...RenderOptFn) error {
+	return writeCodeTerminal(&s, w, opts)
 }
 
 // Encodes and serves the QRCode image
-// TODO: Add options as params
-func (s Static) Serve(w http.ResponseWriter) error {
-	png, err := s.Encode()
-	if err != nil {
-		return err
-	}
-	w.Header().Add("Content-Type", "image/png")
-	w.Write(png)
-	return nil
+func (s Static) Serve(w http.ResponseWriter, opts ...RenderOptFn) error {
+	return serveCode(&s, w, nil, opts)
+}
+
+// Encodes and serves the QRCode image, negotiating the format with the
+// request. The image is served as SVG when the Accept header prefers
+// image/svg+xml, and as PNG otherwise.
+func (s Static) ServeRequest(w http.ResponseWriter, r *http.Request, opts ...RenderOptFn) error {
+	return serveCode(&s, w, r, opts)
 }
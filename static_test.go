@@ -0,0 +1,144 @@
+package qrpix
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Number of goroutines used to hammer a shared code, meant to be run with -race
+const concurrentCalls = 16
+
+func TestStaticConcurrency(t *testing.T) {
+	static := NewStatic(
+		"123e4567-e12b-42d1-a456-426655440000",
+		"Fulano de Tal",
+		"BRASILIA",
+		"***",
+		WithTransactionAmount(1050),
+		WithUnreservedTemplate("80", UnreservedTemplate{
+			GUI:    "com.example",
+			Values: map[string]string{"01": "abc"},
+		}),
+	)
+	expected, err := static.BRCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	png, err := static.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("BRCode should be safe for concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentCalls; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					code, err := static.BRCode()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if code != expected {
+						t.Errorf("expected %s but got %s", expected, code)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("Encode should be safe for concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentCalls; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := static.Encode()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !bytes.Equal(got, png) {
+					t.Error("expected concurrent encodes to match")
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("Serve should be safe for concurrent requests", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := static.Serve(w); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		}))
+		defer server.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentCalls; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := http.Get(server.URL)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				defer res.Body.Close()
+				body, err := io.ReadAll(res.Body)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if res.StatusCode != http.StatusOK {
+					t.Errorf("expected status 200 but got %d: %s", res.StatusCode, body)
+					return
+				}
+				if ct := res.Header.Get("Content-Type"); ct != "image/png" {
+					t.Errorf("expected image/png but got %s", ct)
+				}
+				if !bytes.Equal(body, png) {
+					t.Error("expected served image to match encoded image")
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestDynamicConcurrency(t *testing.T) {
+	dynamic := NewDynamic("pix.example.com/qr/v2/9d36b84f", "Fulano de Tal", "BRASILIA")
+	expected, err := dynamic.BRCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentCalls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, err := dynamic.BRCode()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if code != expected {
+				t.Errorf("expected %s but got %s", expected, code)
+			}
+			rec := httptest.NewRecorder()
+			if err := dynamic.Serve(rec); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,6 +1,7 @@
 package qrpix
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -25,6 +26,8 @@ type Primitive struct {
 	Value string
 
 	parentId string
+	// Set for fields without metadata kept by a lenient parser
+	unknown bool
 }
 
 func (p Primitive) FieldID() string {
@@ -38,9 +41,15 @@ func (p Primitive) validationId() string {
 	return p.parentId + "-" + p.ID
 }
 
+func (p Primitive) validate() error {
+	if p.unknown {
+		return validateUnknownField(p.validationId(), p.Value)
+	}
+	return ValidateField(p.validationId(), p.Value)
+}
+
 func (p Primitive) TLV() (string, string, string, error) {
-	validationId := p.validationId()
-	if err := ValidateField(validationId, p.Value); err != nil {
+	if err := p.validate(); err != nil {
 		return "", "", "", err
 	}
 	// If not value was set, ignore
@@ -72,6 +81,9 @@ func (p Primitive) Code() (string, error) {
 type Template struct {
 	ID     string
 	values map[string]Primitive
+
+	// Set for templates without metadata kept by a lenient parser
+	unknown bool
 }
 
 func (t Template) FieldID() string {
@@ -79,26 +91,37 @@ func (t Template) FieldID() string {
 }
 
 func (t Template) TLV() (string, string, string, error) {
-
+	values := t.Sorted()
+	size := 0
+	for _, p := range values {
+		// Id and length take 2 bytes each, empty values are skipped below
+		if p.Value != "" {
+			size += 4 + len(p.Value)
+		}
+	}
 	b := strings.Builder{}
+	b.Grow(size)
 
-	primitives := t.Sorted()
-
-	if len(t.values) == 0 || t.values == nil {
-		b.WriteString("")
-	} else {
-		for _, p := range primitives {
-			id, length, value, err := p.TLV()
-			if err != nil {
-				return "", "", "", err
-			}
-			b.WriteString(id + length + value)
+	for _, p := range values {
+		id, length, value, err := p.TLV()
+		if err != nil {
+			return "", "", "", err
 		}
+		b.WriteString(id)
+		b.WriteString(length)
+		b.WriteString(value)
+	}
+	if missing := t.missingValues(); len(missing) > 0 && len(t.values) > 0 {
+		return "", "", "", newFieldError(t.ID+"-"+missing[0], "", ReasonNotPresent)
 	}
 	value := b.String()
-	if err := ValidateField(t.ID, value); err != nil {
+	if err := t.validate(value); err != nil {
 		return "", "", "", err
 	}
+	// If no value was set, ignore
+	if value == "" {
+		return "", "", "", nil
+	}
 
 	limit, err := convertLength(value)
 	if err != nil {
@@ -108,6 +131,13 @@ func (t Template) TLV() (string, string, string, error) {
 	return t.ID, limit, value, nil
 }
 
+func (t Template) validate(value string) error {
+	if t.unknown {
+		return validateUnknownField(t.ID, value)
+	}
+	return ValidateField(t.ID, value)
+}
+
 func (t Template) Code() (string, error) {
 	id, length, value, err := t.TLV()
 	if err != nil {
@@ -123,30 +153,44 @@ func (t *Template) AddValue(id, value string) {
 	t.values[id] = Primitive{ID: id, Value: value, parentId: t.ID}
 }
 
+// Adds a value without metadata, which is only checked against the TLV length limit
+func (t *Template) addUnknownValue(id, value string) {
+	if t.values == nil {
+		t.values = map[string]Primitive{}
+	}
+	t.values[id] = Primitive{ID: id, Value: value, parentId: t.ID, unknown: true}
+}
+
 func (t Template) Unwrap() map[string]Primitive {
 	return t.values
 }
 
 // Ensures consistency
 func (t Template) Sorted() []Primitive {
-	primitives := []Primitive{}
+	primitives := make([]Primitive, 0, len(t.values))
 	for _, v := range t.values {
 		primitives = append(primitives, v)
 	}
 	slices.SortFunc[Primitive](primitives, func(a, b Primitive) bool {
-		aid, _ := strconv.Atoi(a.ID)
-		bid, _ := strconv.Atoi(b.ID)
-		return aid < bid
+		return a.ID < b.ID
 	})
 	return primitives
 }
 
+// Two digit lengths, precomputed so encoding doesn't allocate them
+var fieldLengths = formatLengths()
+
+func formatLengths() (lengths [maxFieldSize + 1]string) {
+	for i := range lengths {
+		lengths[i] = fmt.Sprintf("%02d", i)
+	}
+	return lengths
+}
+
 func convertLength(value string) (string, error) {
 	l := len(value)
-	switch {
-	case l < 10:
-		return "0" + strconv.Itoa(l), nil
-	default:
-		return strconv.Itoa(l), nil
+	if l < len(fieldLengths) {
+		return fieldLengths[l], nil
 	}
+	return strconv.Itoa(l), nil
 }
@@ -0,0 +1,177 @@
+package qrpix
+
+import (
+	"sort"
+	"strings"
+)
+
+// Validates every field of the builder, returning all problems found instead
+// of stopping at the first one. Checks required fields, field and template
+// sizes, the chave format and the transaction amount format.
+func (b Builder) Validate() []error {
+	return b.validate(CharsetUTF8)
+}
+
+func (b Builder) validate(c Charset) []error {
+	var errs []error
+
+	for _, id := range requiredFieldIDs("") {
+		if _, ok := b[id]; !ok {
+			errs = append(errs, newFieldError(id, "", ReasonNotPresent))
+		}
+	}
+
+	for _, tlv := range b.Sorted() {
+		switch v := tlv.(type) {
+		case *Primitive:
+			_, err := v.check(c)
+			errs = appendError(errs, err)
+		case Primitive:
+			_, err := v.check(c)
+			errs = appendError(errs, err)
+		case *Template:
+			errs = append(errs, v.check(c)...)
+		case Template:
+			errs = append(errs, v.check(c)...)
+		default:
+			_, _, _, err := tlv.TLV()
+			errs = appendError(errs, err)
+		}
+	}
+
+	return errs
+}
+
+func appendError(errs []error, err error) []error {
+	if err != nil {
+		return append(errs, err)
+	}
+	return errs
+}
+
+// Sorted ids of the required top level fields, computed once from
+// IDMetadata. The CRC is excluded since it's added when building.
+var requiredIDs = collectRequiredIDs()
+
+func collectRequiredIDs() []string {
+	var ids []string
+	for n, field := range metadataIndex {
+		if field.meta != nil && field.meta.Required && n != 63 {
+			ids = append(ids, fieldLengths[n])
+		}
+	}
+	return ids
+}
+
+// Returns the sorted ids of required fields. With a parent id, returns the
+// required values of that template.
+func requiredFieldIDs(parentId string) []string {
+	if parentId == "" {
+		return requiredIDs
+	}
+	if !isNumericID(parentId) {
+		return nil
+	}
+	n, _ := twoDigits(parentId)
+	return metadataIndex[n].required
+}
+
+// Required values of the merchant account information of dynamic codes
+var dynamicAccountRequiredIDs = replaceID(requiredFieldIDs("26"), "01", "25")
+
+// Returns the sorted ids of the values a template requires. Dynamic codes
+// carry the payload URL (26-25) in place of the chave (26-01), so templates
+// with a URL don't require a chave.
+func requiredValueIDs(parentId string, hasURL bool) []string {
+	if parentId == "26" && hasURL {
+		return dynamicAccountRequiredIDs
+	}
+	return requiredFieldIDs(parentId)
+}
+
+// Returns a sorted copy of ids with old replaced by id
+func replaceID(ids []string, old, id string) []string {
+	replaced := make([]string, 0, len(ids))
+	for _, v := range ids {
+		if v == old {
+			v = id
+		}
+		replaced = append(replaced, v)
+	}
+	sort.Strings(replaced)
+	return replaced
+}
+
+// Returns the required values missing from the template. Values set to an
+// empty string are reported by the values themselves, except for the URL
+// which is only required in place of the chave.
+func (t Template) missingValues() []string {
+	if t.unknown {
+		return nil
+	}
+	_, hasURL := t.values["25"]
+	var missing []string
+	for _, id := range requiredValueIDs(t.ID, hasURL) {
+		if v, ok := t.values[id]; !ok || (id == "25" && v.Value == "") {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// Checks the primitive with the charset policy applied, returning the
+// converted primitive and the first problem found.
+func (p Primitive) check(c Charset) (Primitive, error) {
+	path := p.validationId()
+	if c == CharsetTransliterate {
+		value, err := transliterate(p.Value)
+		if err != nil {
+			return p, newFieldError(path, p.Value, ReasonInvalidCharset)
+		}
+		p.Value = value
+	}
+	if err := c.validate(p.Value); err != nil {
+		return p, newFieldError(path, p.Value, ReasonInvalidCharset)
+	}
+	if err := p.validate(); err != nil {
+		return p, err
+	}
+	if p.Value == "" || p.unknown {
+		return p, nil
+	}
+
+	switch path {
+	case "26-01":
+		if err := ValidateKey(p.Value); err != nil {
+			return p, newFieldError(path, p.Value, ReasonInvalidKey)
+		}
+	}
+	return p, nil
+}
+
+// Checks every template value, required values and the template size
+func (t Template) check(c Charset) []error {
+	var errs []error
+
+	for _, id := range t.missingValues() {
+		errs = append(errs, newFieldError(t.ID+"-"+id, "", ReasonNotPresent))
+	}
+
+	b := strings.Builder{}
+	for _, v := range t.Sorted() {
+		p, err := v.check(c)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if code, err := p.Code(); err == nil {
+			b.WriteString(code)
+		}
+	}
+
+	// Template sizes are only meaningful when every value is valid
+	if len(errs) == 0 {
+		errs = appendError(errs, t.validate(b.String()))
+	}
+	return errs
+}
@@ -0,0 +1,138 @@
+package qrpix
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("valid builder should return no errors", func(t *testing.T) {
+		builder := Builder{}
+		builder.AddPayloadFormatIndicator(PayloadFormatIndicator)
+		builder.AddMerchantAccountInformation(PIXGui, "maria@email.com")
+		builder.AddMerchantCategoryCode("0000")
+		builder.AddTransactionCurrency("986")
+		builder.AddTransactionAmount(1050)
+		builder.AddCountryCode("BR")
+		builder.AddMerchantName("Maria")
+		builder.AddMerchantCity("BRASILIA")
+
+		if errs := builder.Validate(); len(errs) != 0 {
+			t.Errorf("expected no errors but got %v", errs)
+		}
+	})
+
+	t.Run("required field ids should be listed by parent", func(t *testing.T) {
+		cases := []struct {
+			parent   string
+			expected string
+		}{
+			{parent: "", expected: "00,26,52,53,58,59,60"},
+			{parent: "26", expected: "00,01"},
+			{parent: "62", expected: ""},
+			{parent: "85", expected: "00"},
+		}
+		for _, c := range cases {
+			got := strings.Join(requiredFieldIDs(c.parent), ",")
+			if got != c.expected {
+				t.Errorf("parent %q: expected %q but got %q", c.parent, c.expected, got)
+			}
+		}
+	})
+
+	t.Run("builder should report every problem", func(t *testing.T) {
+		builder := Builder{}
+		builder.AddPayloadFormatIndicator(PayloadFormatIndicator)
+		builder.AddMerchantAccountInformation("", "not a key")
+		builder.AddMerchantCategoryCode("000")
+		builder.AddTransactionCurrency("9860")
+		builder.Add(&Primitive{ID: "54", Value: "1e3"})
+		builder.AddMerchantName("Maria")
+
+		expected := []struct {
+			path   string
+			reason FieldErrorReason
+		}{
+			{path: "58", reason: ReasonNotPresent},
+			{path: "60", reason: ReasonNotPresent},
+			{path: "26-00", reason: ReasonRequired},
+			{path: "26-01", reason: ReasonInvalidKey},
+			{path: "52", reason: ReasonBelowMin},
+			{path: "53", reason: ReasonAboveMax},
+			{path: "54", reason: ReasonInvalidAmount},
+		}
+
+		errs := builder.Validate()
+		if len(errs) != len(expected) {
+			t.Fatalf("expected %v errors but got %v", len(expected), errs)
+		}
+		for i, e := range expected {
+			var fieldErr *FieldError
+			if !errors.As(errs[i], &fieldErr) {
+				t.Errorf("expected FieldError but got: %v", errs[i])
+				continue
+			}
+			if fieldErr.Path() != e.path || fieldErr.Reason != e.reason {
+				t.Errorf("expected %s (%s) but got %s (%s)", e.path, e.reason, fieldErr.Path(), fieldErr.Reason)
+			}
+		}
+	})
+
+	t.Run("builder should report template sizes", func(t *testing.T) {
+		builder := Builder{}
+		builder.AddUnreservedTemplate("80", UnreservedTemplate{
+			GUI:    "com.example",
+			Values: map[string]string{"01": strings.Repeat("a", 50), "02": strings.Repeat("b", 50)},
+		})
+
+		errs := builder.Validate()
+		var found bool
+		for _, err := range errs {
+			var fieldErr *FieldError
+			if errors.As(err, &fieldErr) && fieldErr.Path() == "80" {
+				found = fieldErr.Reason == ReasonAboveMax
+			}
+		}
+		if !found {
+			t.Errorf("expected template size error for 80 but got %v", errs)
+		}
+	})
+
+	t.Run("static should report every problem", func(t *testing.T) {
+		qr := NewStatic("", "", "Uma cidade com um nome muito longo", "", WithMerchantCategoryCode("0"))
+		errs := qr.Validate()
+
+		expected := map[string]FieldErrorReason{
+			"26-01": ReasonRequired,
+			"52":    ReasonBelowMin,
+			"59":    ReasonRequired,
+			"60":    ReasonAboveMax,
+		}
+		if len(errs) != len(expected) {
+			t.Fatalf("expected %v errors but got %v", len(expected), errs)
+		}
+		for _, err := range errs {
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Errorf("expected FieldError but got: %v", err)
+				continue
+			}
+			if expected[fieldErr.Path()] != fieldErr.Reason {
+				t.Errorf("unexpected error %s (%s)", fieldErr.Path(), fieldErr.Reason)
+			}
+		}
+	})
+
+	t.Run("static validation should apply options", func(t *testing.T) {
+		qr := NewStatic("(11) 99999-9999", "Maria", "São José dos Campos", "abc", WithKeyNormalization(), WithCharset(CharsetASCII))
+		if errs := qr.Validate(); len(errs) != 1 || !errors.Is(errs[0], ErrInvalidCharset) {
+			t.Errorf("expected a single charset error but got %v", errs)
+		}
+
+		qr = NewStatic("(11) 99999-9999", "Maria", "São José dos Campos", "abc", WithKeyNormalization(), WithCharset(CharsetASCII), WithSanitization())
+		if errs := qr.Validate(); len(errs) != 0 {
+			t.Errorf("expected no errors but got %v", errs)
+		}
+	})
+}